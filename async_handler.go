@@ -0,0 +1,327 @@
+package slogx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// OverflowPolicy decides what AsyncHandler does with a record when its queue is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits for room in the queue.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest drops the record.
+	OverflowDropNewest
+	// OverflowDropOldest drops the oldest queued record to make room.
+	OverflowDropOldest
+	// OverflowDropBelowLevel drops the record when it is below DropLevel, and waits for room otherwise.
+	OverflowDropBelowLevel
+)
+
+// ErrAsyncClosed is returned by AsyncHandler.Handle after Close.
+var ErrAsyncClosed = errors.New("slogx: async handler is closed")
+
+// DroppedKey is the key of the number of dropped records, in the record reporting them.
+const DroppedKey = "dropped"
+
+type AsyncHandlerOptions struct {
+	// QueueSize is the number of records waiting to be handled, default to 1024.
+	QueueSize int
+	// Overflow is the policy when the queue is full, default to OverflowBlock.
+	Overflow OverflowPolicy
+	// DropLevel is the level below which OverflowDropBelowLevel drops records, default to warn.
+	DropLevel slog.Leveler
+	// BatchSize is the max number of records handled in a batch, default to 128.
+	BatchSize int
+	// BatchWriter, when the handler writes to it, is flushed after every batch,
+	// so a batch of records is written at once.
+	BatchWriter *BatchWriter
+	// DropReportInterval is the interval the number of dropped records is logged at, default to 10s.
+	// A negative interval disables the report.
+	DropReportInterval time.Duration
+}
+
+// AsyncHandler queues the records and handles them in a background goroutine,
+// so callers are not slowed down by the output.
+type AsyncHandler struct {
+	state   *asyncState
+	handler slog.Handler
+}
+
+// asyncState is shared by an AsyncHandler and the handlers derived from it.
+type asyncState struct {
+	opts AsyncHandlerOptions
+	// handler reports the dropped records
+	handler slog.Handler
+
+	mu      sync.Mutex
+	notFull *sync.Cond
+	queue   []asyncEntry
+	dropped int
+	closed  bool
+
+	wake chan struct{}
+	done chan struct{}
+}
+
+// asyncEntry is a queued record, or a flush request when flushed is not nil.
+type asyncEntry struct {
+	ctx     context.Context
+	handler slog.Handler
+	record  slog.Record
+	flushed chan struct{}
+}
+
+// NewAsyncHandler returns a handler queueing the records of handler, it must be closed to stop its goroutine.
+func NewAsyncHandler(handler slog.Handler, opts *AsyncHandlerOptions) *AsyncHandler {
+	state := &asyncState{handler: handler, wake: make(chan struct{}, 1), done: make(chan struct{})}
+	if opts != nil {
+		state.opts = *opts
+	}
+	if state.opts.QueueSize <= 0 {
+		state.opts.QueueSize = 1024
+	}
+	if state.opts.BatchSize <= 0 {
+		state.opts.BatchSize = 128
+	}
+	if state.opts.DropLevel == nil {
+		state.opts.DropLevel = slog.LevelWarn
+	}
+	if state.opts.DropReportInterval == 0 {
+		state.opts.DropReportInterval = 10 * time.Second
+	}
+	state.notFull = sync.NewCond(&state.mu)
+
+	go state.run()
+	return &AsyncHandler{state: state, handler: handler}
+}
+
+func (h *AsyncHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return h.handler.Enabled(ctx, l)
+}
+
+// Handle queues r, it blocks or drops a record when the queue is full, depending on the Overflow policy.
+func (h *AsyncHandler) Handle(ctx context.Context, r slog.Record) error {
+	// the caller may reuse the attrs of r
+	entry := asyncEntry{ctx: context.WithoutCancel(ctx), handler: h.handler, record: r.Clone()}
+	s := h.state
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for !s.closed && len(s.queue) >= s.opts.QueueSize {
+		switch {
+		case s.opts.Overflow == OverflowDropNewest,
+			s.opts.Overflow == OverflowDropBelowLevel && r.Level < s.opts.DropLevel.Level():
+			s.dropped++
+			return nil
+		case s.opts.Overflow == OverflowDropOldest && s.dropOldest():
+			s.dropped++
+			continue
+		}
+		s.notFull.Wait()
+	}
+	if s.closed {
+		return ErrAsyncClosed
+	}
+
+	s.queue = append(s.queue, entry)
+	s.notify()
+	return nil
+}
+
+// dropOldest removes the oldest queued record, flush requests are kept.
+func (s *asyncState) dropOldest() bool {
+	for i, e := range s.queue {
+		if e.flushed == nil {
+			s.queue = append(s.queue[:i], s.queue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (s *asyncState) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (h *AsyncHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return &AsyncHandler{state: h.state, handler: h.handler.WithAttrs(attrs)}
+}
+
+func (h *AsyncHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &AsyncHandler{state: h.state, handler: h.handler.WithGroup(name)}
+}
+
+// Flush waits until the records queued before it are written,
+// it returns the context error when ctx is done first.
+func (h *AsyncHandler) Flush(ctx context.Context) error {
+	s := h.state
+	flushed := make(chan struct{})
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrAsyncClosed
+	}
+	// a flush request does not count in the queue size, it never waits
+	s.queue = append(s.queue, asyncEntry{flushed: flushed})
+	s.notify()
+	s.mu.Unlock()
+
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Sync flushes the queued records, see Flush.
+func (h *AsyncHandler) Sync() error {
+	return h.Flush(context.Background())
+}
+
+// Close writes the queued records and stops the goroutine,
+// it returns the context error when ctx is done first, the records keep being written in the background.
+// Records handled after Close are rejected with ErrAsyncClosed.
+func (h *AsyncHandler) Close(ctx context.Context) error {
+	s := h.state
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		// the records waiting for room are rejected
+		s.notFull.Broadcast()
+		s.notify()
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *asyncState) run() {
+	defer close(s.done)
+
+	var tick <-chan time.Time
+	if s.opts.DropReportInterval > 0 {
+		ticker := time.NewTicker(s.opts.DropReportInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-s.wake:
+		case <-tick:
+			s.reportDropped()
+			continue
+		}
+
+		for {
+			s.mu.Lock()
+			n := min(len(s.queue), s.opts.BatchSize)
+			batch := make([]asyncEntry, n)
+			copy(batch, s.queue)
+			s.queue = append(s.queue[:0], s.queue[n:]...)
+			closed := s.closed
+			s.notFull.Broadcast()
+			s.mu.Unlock()
+
+			if n == 0 {
+				if closed {
+					s.reportDropped()
+					return
+				}
+				break
+			}
+			s.handleBatch(batch)
+		}
+	}
+}
+
+func (s *asyncState) handleBatch(batch []asyncEntry) {
+	var flushed []chan struct{}
+	for _, e := range batch {
+		if e.flushed != nil {
+			flushed = append(flushed, e.flushed)
+			continue
+		}
+		if err := e.handler.Handle(e.ctx, e.record); err != nil {
+			// there is no caller to return the error to
+			os.Stderr.WriteString("slogx: failed to handle log record: " + err.Error() + "\n")
+		}
+	}
+	if s.opts.BatchWriter != nil {
+		if err := s.opts.BatchWriter.Flush(); err != nil {
+			os.Stderr.WriteString("slogx: failed to write log records: " + err.Error() + "\n")
+		}
+	}
+	for _, ch := range flushed {
+		close(ch)
+	}
+}
+
+// reportDropped logs the number of records dropped since the last report.
+func (s *asyncState) reportDropped() {
+	s.mu.Lock()
+	dropped := s.dropped
+	s.dropped = 0
+	s.mu.Unlock()
+	if dropped == 0 {
+		return
+	}
+
+	r := slog.NewRecord(time.Now(), slog.LevelWarn, "slogx: dropped log records, the queue is full", 0)
+	r.AddAttrs(slog.Int(DroppedKey, dropped))
+	s.handleBatch([]asyncEntry{{ctx: context.Background(), handler: s.handler, record: r}})
+}
+
+// BatchWriter buffers what a handler writes until Flush,
+// it is only safe for the handlers of a single AsyncHandler, which flushes it after every batch.
+type BatchWriter struct {
+	w   io.Writer
+	buf []byte
+}
+
+func NewBatchWriter(w io.Writer) *BatchWriter {
+	return &BatchWriter{w: w}
+}
+
+func (b *BatchWriter) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	return len(p), nil
+}
+
+// Flush writes the buffered data at once, it is dropped when the write fails.
+func (b *BatchWriter) Flush() error {
+	if len(b.buf) == 0 {
+		return nil
+	}
+	_, err := b.w.Write(b.buf)
+	b.buf = b.buf[:0]
+	return err
+}
+
+var (
+	_ slog.Handler = (*AsyncHandler)(nil)
+	_ Syncer       = (*AsyncHandler)(nil)
+)
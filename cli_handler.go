@@ -3,11 +3,16 @@ package slogx
 import (
 	"context"
 	"fmt"
-	"github.com/fatih/color"
-	"github.com/ttys3/slogx/internal"
+	"hash/fnv"
 	"io"
 	"log/slog"
+	"strings"
 	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+	"github.com/ttys3/slogx/internal"
 )
 
 var bold = color.New(color.Bold)
@@ -18,38 +23,127 @@ func init() {
 
 // Colors mapping.
 var Colors = map[slog.Level]*color.Color{
+	LevelTrace:      color.New(color.FgHiBlack),
 	slog.LevelDebug: color.New(color.FgWhite),
 	slog.LevelInfo:  color.New(color.FgBlue),
+	LevelNotice:     color.New(color.FgCyan),
 	slog.LevelWarn:  color.New(color.FgYellow),
 	slog.LevelError: color.New(color.FgRed),
+	LevelFatal:      color.New(color.FgHiRed),
 }
 
 // Strings mapping.
 var Strings = map[slog.Level]string{
+	LevelTrace:      "·",
 	slog.LevelDebug: "•",
 	slog.LevelInfo:  "•",
+	LevelNotice:     "•",
 	slog.LevelWarn:  "•",
 	slog.LevelError: "⨯",
+	LevelFatal:      "⨯",
 }
 
-type CliHandler struct {
+// levelMapping returns the value of l in m, or of the highest level of m not above l,
+// or of the lowest level of m when they are all above l.
+func levelMapping[T any](m map[slog.Level]T, l slog.Level) T {
+	if v, ok := m[l]; ok {
+		return v
+	}
+	var found, lowest T
+	var foundLevel, lowestLevel slog.Level
+	hasFound, hasLowest := false, false
+	for level, v := range m {
+		if level <= l && (!hasFound || level > foundLevel) {
+			found, foundLevel, hasFound = v, level, true
+		}
+		if !hasLowest || level < lowestLevel {
+			lowest, lowestLevel, hasLowest = v, level, true
+		}
+	}
+	if !hasFound {
+		return lowest
+	}
+	return found
+}
+
+// task completion symbol and color, a failed task uses the error level ones.
+var (
+	taskDoneColor  = color.New(color.FgGreen)
+	taskDoneString = "✓"
+)
+
+// TagColors is the palette of tag colors, a tag always gets the same color.
+var TagColors = []*color.Color{
+	color.New(color.FgCyan),
+	color.New(color.FgMagenta),
+	color.New(color.FgGreen),
+	color.New(color.FgHiBlue),
+	color.New(color.FgHiYellow),
+	color.New(color.FgHiMagenta),
+	color.New(color.FgHiCyan),
+	color.New(color.FgHiGreen),
+}
+
+const defaultTagWidth = 8
+
+// CollapseMode controls how repeated handler attributes are rendered.
+type CollapseMode int
+
+const (
+	// CollapseNone always prints the handler attributes.
+	CollapseNone CollapseMode = iota
+	// CollapseMark prints a dim CollapseMarker in place of unchanged handler attributes.
+	CollapseMark
+	// CollapseHide omits unchanged handler attributes.
+	CollapseHide
+)
+
+// CollapseMarker is printed by CollapseMark in place of repeated handler attributes.
+var CollapseMarker = "〃"
+
+var collapseColor = color.New(color.Faint)
+
+// cliState is shared by a CliHandler and the handlers derived from it.
+type cliState struct {
 	mu sync.Mutex
-	w  io.Writer
+
+	// lastAttrs identifies the handler attributes of the last written line
+	lastAttrs string
+}
+
+type CliHandler struct {
+	state *cliState
+	w     io.Writer
 
 	opts *CliHandlerOptions
 
 	attrsPrefix []slog.Attr
 
 	groupPrefix string
+
+	tag string
 }
 
 type CliHandlerOptions struct {
 	DisableColor bool
+
+	// TagKey is the attr key rendered as a [tag] prefix after the level symbol
+	// instead of a trailing key=value, for example "component".
+	TagKey string
+	// TagFromGroup uses the outermost group name as tag.
+	TagFromGroup bool
+	// TagWidth is the width the tag is padded to, default to 8, longer tags are truncated with an ellipsis.
+	TagWidth int
+
+	// CollapseAttrs collapses the handler attributes (added by With)
+	// when they are the same as the ones of the previous line.
+	CollapseAttrs CollapseMode
+
 	slog.HandlerOptions
 }
 
 func NewCliHandler(w io.Writer, opts *CliHandlerOptions) *CliHandler {
-	return &CliHandler{w: w, opts: opts}
+	return &CliHandler{state: &cliState{}, w: w, opts: opts}
 }
 
 func (h *CliHandler) Enabled(ctx context.Context, l slog.Level) bool {
@@ -66,7 +160,14 @@ func (h *CliHandler) Handle(ctx context.Context, r slog.Record) error {
 	buf := internal.NewBuffer()
 	defer buf.Free()
 
-	theColor := Colors[r.Level]
+	theColor := levelColor(r.Level)
+	levelEmoji := levelMapping(Strings, r.Level)
+
+	depth, ev := taskDepth(ctx)
+	if ev != nil && ev.end && ev.err == nil {
+		theColor = taskDoneColor
+		levelEmoji = taskDoneString
+	}
 
 	if h.opts.DisableColor {
 		theColor.DisableColor()
@@ -74,35 +175,86 @@ func (h *CliHandler) Handle(ctx context.Context, r slog.Record) error {
 		theColor.EnableColor()
 	}
 
-	levelEmoji := Strings[r.Level]
+	// indent records nested in a task
+	buf.WriteString(strings.Repeat("  ", depth))
+
 	padding := 4
 	coloredLevel := theColor.Sprintf("%s", bold.Sprintf("%*s", padding, levelEmoji))
 	buf.WriteString(coloredLevel)
 
+	// the record tag overrides the handler one
+	tag := h.tag
+	if h.opts.TagKey != "" && h.groupPrefix == "" && r.NumAttrs() > 0 {
+		r.Attrs(func(attr slog.Attr) bool {
+			if attr.Key == h.opts.TagKey {
+				tag = attr.Value.String()
+				return false
+			}
+			return true
+		})
+	}
+	h.appendTag(buf, tag)
+
 	buf.WriteString(" ")
 	buf.WriteString(fmt.Sprintf("%-25s", r.Message))
 
 	buf.WriteString("\t\t")
 
+	if ev != nil && ev.end {
+		buf.WriteString(" ")
+		buf.WriteString(theColor.Sprint(formatElapsed(ev.elapsed)))
+	}
+
 	// write handler attributes
+	attrsBuf := internal.NewBuffer()
+	defer attrsBuf.Free()
 	if len(h.attrsPrefix) > 0 {
 		for _, attr := range h.attrsPrefix {
-			h.appendAttr(buf, attr, theColor, h.groupPrefix)
+			h.appendAttr(attrsBuf, attr, theColor, h.groupPrefix)
 		}
 	}
 
 	// write attributes
+	recordBuf := internal.NewBuffer()
+	defer recordBuf.Free()
 	if r.NumAttrs() > 0 {
 		r.Attrs(func(attr slog.Attr) bool {
-			h.appendAttr(buf, attr, theColor, h.groupPrefix)
+			// the task group is rendered as header or completion line
+			if ev != nil && attr.Key == TaskKey {
+				return true
+			}
+			if h.opts.TagKey != "" && h.groupPrefix == "" && attr.Key == h.opts.TagKey {
+				return true
+			}
+			h.appendAttr(recordBuf, attr, theColor, h.groupPrefix)
 			return true
 		})
 	}
 
+	h.state.mu.Lock()
+	defer h.state.mu.Unlock()
+
+	if h.opts.CollapseAttrs != CollapseNone {
+		attrsKey := h.attrsKey()
+		if attrsKey != "" && attrsKey == h.state.lastAttrs {
+			attrsBuf.Reset()
+			if h.opts.CollapseAttrs == CollapseMark {
+				if h.opts.DisableColor {
+					collapseColor.DisableColor()
+				} else {
+					collapseColor.EnableColor()
+				}
+				attrsBuf.WriteString(" ")
+				attrsBuf.WriteString(collapseColor.Sprint(CollapseMarker))
+			}
+		}
+		h.state.lastAttrs = attrsKey
+	}
+
+	buf.Write(attrsBuf.Bytes())
+	buf.Write(recordBuf.Bytes())
 	buf.WriteByte('\n')
 
-	h.mu.Lock()
-	defer h.mu.Unlock()
 	_, err := h.w.Write(buf.Bytes())
 	if err != nil {
 		return err
@@ -138,10 +290,72 @@ func (h *CliHandler) appendAttr(buf *internal.Buffer, attr slog.Attr, theColor *
 	// }
 }
 
+func (h *CliHandler) appendTag(buf *internal.Buffer, tag string) {
+	if tag == "" && h.opts.TagKey == "" && !h.opts.TagFromGroup {
+		return
+	}
+
+	width := h.opts.TagWidth
+	if width <= 0 {
+		width = defaultTagWidth
+	}
+
+	buf.WriteString(" ")
+	if tag == "" {
+		// keep messages aligned with tagged lines
+		buf.WriteString(strings.Repeat(" ", width+2))
+		return
+	}
+
+	tagColor := TagColors[tagColorIndex(tag)]
+	if h.opts.DisableColor {
+		tagColor.DisableColor()
+	} else {
+		tagColor.EnableColor()
+	}
+	// the color is picked from the full tag, so truncated tags keep their color
+	n := utf8.RuneCountInString(tag)
+	if n > width {
+		tag = string([]rune(tag)[:max(width-1, 0)]) + "…"
+		n = width
+	}
+	// padded by runes, %-*s pads by bytes
+	buf.WriteString(tagColor.Sprint("[" + tag + "]" + strings.Repeat(" ", width-n)))
+}
+
+// tagColorIndex hashes tag to a stable index into TagColors.
+func tagColorIndex(tag string) int {
+	hash := fnv.New32a()
+	hash.Write([]byte(tag))
+	return int(hash.Sum32() % uint32(len(TagColors)))
+}
+
+// attrsKey identifies the handler attributes, for CollapseAttrs.
+func (h *CliHandler) attrsKey() string {
+	if len(h.attrsPrefix) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	for _, attr := range h.attrsPrefix {
+		sb.WriteString(h.groupPrefix)
+		sb.WriteString(attr.String())
+		sb.WriteByte(' ')
+	}
+	return sb.String()
+}
+
+// formatElapsed rounds d for display, keeping sub-millisecond durations readable.
+func formatElapsed(d time.Duration) string {
+	if d >= time.Millisecond {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(time.Microsecond).String()
+}
+
 func (h *CliHandler) clone() *CliHandler {
 	attrsPrefix := make([]slog.Attr, len(h.attrsPrefix))
 	copy(attrsPrefix, h.attrsPrefix)
-	return &CliHandler{w: h.w, opts: h.opts, attrsPrefix: attrsPrefix, groupPrefix: h.groupPrefix}
+	return &CliHandler{state: h.state, w: h.w, opts: h.opts, attrsPrefix: attrsPrefix, groupPrefix: h.groupPrefix, tag: h.tag}
 }
 
 func (h *CliHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
@@ -150,7 +364,14 @@ func (h *CliHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	}
 
 	cloned := h.clone()
-	cloned.attrsPrefix = append(cloned.attrsPrefix, attrs...)
+	for _, attr := range attrs {
+		// only top level attrs can be the tag
+		if h.opts.TagKey != "" && attr.Key == h.opts.TagKey && h.groupPrefix == "" {
+			cloned.tag = attr.Value.String()
+			continue
+		}
+		cloned.attrsPrefix = append(cloned.attrsPrefix, attr)
+	}
 	return cloned
 }
 
@@ -159,6 +380,11 @@ func (h *CliHandler) WithGroup(name string) slog.Handler {
 		return h
 	}
 	cloned := h.clone()
+	// the outermost group becomes the tag instead of a key prefix
+	if h.opts.TagFromGroup && h.groupPrefix == "" && h.tag == "" {
+		cloned.tag = name
+		return cloned
+	}
 	cloned.groupPrefix += name + "."
 	return cloned
 }
@@ -0,0 +1,256 @@
+package slogx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the configuration of a logger, it can be loaded from a JSON, YAML or TOML file with LoadConfig.
+//
+//	level: info
+//	package_levels: info,github.com/acme/db=debug
+//	format: cli
+//	output: stderr
+//	sinks:
+//	  - format: json
+//	    output: /var/log/app.log
+//	    level: debug
+//	sampling:
+//	  first: 100
+//	  thereafter: 10
+//	redact:
+//	  keys: [password, "*token*"]
+//	  patterns: [default]
+//	  replace: partial:4
+type Config struct {
+	Level         string `json:"level,omitempty" yaml:"level,omitempty" toml:"level,omitempty"`
+	PackageLevels string `json:"package_levels,omitempty" yaml:"package_levels,omitempty" toml:"package_levels,omitempty"`
+	Format        string `json:"format,omitempty" yaml:"format,omitempty" toml:"format,omitempty"`
+	Output        string `json:"output,omitempty" yaml:"output,omitempty" toml:"output,omitempty"`
+
+	DisableSource bool `json:"disable_source,omitempty" yaml:"disable_source,omitempty" toml:"disable_source,omitempty"`
+	FullSource    bool `json:"full_source,omitempty" yaml:"full_source,omitempty" toml:"full_source,omitempty"`
+	DisableTime   bool `json:"disable_time,omitempty" yaml:"disable_time,omitempty" toml:"disable_time,omitempty"`
+	DisableColor  bool `json:"disable_color,omitempty" yaml:"disable_color,omitempty" toml:"disable_color,omitempty"`
+	Tracing       bool `json:"tracing,omitempty" yaml:"tracing,omitempty" toml:"tracing,omitempty"`
+
+	Sinks    []SinkConfig    `json:"sinks,omitempty" yaml:"sinks,omitempty" toml:"sinks,omitempty"`
+	Sampling *SamplingConfig `json:"sampling,omitempty" yaml:"sampling,omitempty" toml:"sampling,omitempty"`
+	Redact   *RedactConfig   `json:"redact,omitempty" yaml:"redact,omitempty" toml:"redact,omitempty"`
+}
+
+// SinkConfig is an output besides the Output, see WithSink.
+type SinkConfig struct {
+	Format string `json:"format,omitempty" yaml:"format,omitempty" toml:"format,omitempty"`
+	Output string `json:"output,omitempty" yaml:"output,omitempty" toml:"output,omitempty"`
+	Level  string `json:"level,omitempty" yaml:"level,omitempty" toml:"level,omitempty"`
+}
+
+// SamplingConfig configures the sampling, see SamplingHandlerOptions.
+type SamplingConfig struct {
+	Tick       Duration `json:"tick,omitempty" yaml:"tick,omitempty" toml:"tick,omitempty"`
+	First      int      `json:"first,omitempty" yaml:"first,omitempty" toml:"first,omitempty"`
+	Thereafter int      `json:"thereafter,omitempty" yaml:"thereafter,omitempty" toml:"thereafter,omitempty"`
+	KeyAttrs   []string `json:"key_attrs,omitempty" yaml:"key_attrs,omitempty" toml:"key_attrs,omitempty"`
+}
+
+// RedactConfig configures the redaction, see RedactHandlerOptions.
+type RedactConfig struct {
+	Keys []string `json:"keys,omitempty" yaml:"keys,omitempty" toml:"keys,omitempty"`
+	// Patterns are regexps, or the names of the built-in ones: email, card_number, bearer_token, aws_key, jwt,
+	// and default for all of them.
+	Patterns []string `json:"patterns,omitempty" yaml:"patterns,omitempty" toml:"patterns,omitempty"`
+	// Replace is mask (default), partial:N to keep the last N chars, or hash:SALT.
+	Replace string `json:"replace,omitempty" yaml:"replace,omitempty" toml:"replace,omitempty"`
+}
+
+// redactPatterns are the built-in patterns by name, for RedactConfig.
+var redactPatterns = map[string][]*regexp.Regexp{
+	"email":        {RedactEmail},
+	"card_number":  {RedactCardNumber},
+	"bearer_token": {RedactBearerToken},
+	"aws_key":      {RedactAWSKey},
+	"jwt":          {RedactJWT},
+	"default":      DefaultRedactPatterns,
+}
+
+// Duration is a time.Duration written like 1s or 5m in config files.
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// LoadConfig loads the config file at path, its format is found from the extension:
+// .json, .yaml, .yml or .toml. Unknown fields are errors.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c, err := DecodeConfig(bytes.NewReader(data), strings.TrimPrefix(filepath.Ext(path), "."))
+	if err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// DecodeConfig decodes a config in format json, yaml or toml.
+func DecodeConfig(r io.Reader, format string) (*Config, error) {
+	c := &Config{}
+	switch strings.ToLower(format) {
+	case "json":
+		dec := json.NewDecoder(r)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(c); err != nil {
+			return nil, err
+		}
+	case "yaml", "yml":
+		dec := yaml.NewDecoder(r)
+		dec.KnownFields(true)
+		// an empty file is an empty config
+		if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+	case "toml":
+		md, err := toml.NewDecoder(r).Decode(c)
+		if err != nil {
+			return nil, err
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return nil, fmt.Errorf("unknown field %q", undecoded[0].String())
+		}
+	default:
+		return nil, fmt.Errorf("unknown config format %q, want json, yaml or toml", format)
+	}
+	return c, nil
+}
+
+// Validate returns the errors of invalid or conflicting settings, the outputs are not opened.
+func (c *Config) Validate() error {
+	o, err := c.options()
+	if err != nil {
+		return err
+	}
+	errs := []error{o.validate()}
+	for _, sink := range o.Sinks {
+		so := sink.options(o)
+		if err := so.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("sink %s: %w", so.Output, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NewHandler creates the handler of the config, its outputs are closed by Shutdown.
+func (c *Config) NewHandler() (slog.Handler, error) {
+	o, err := c.options()
+	if err != nil {
+		return nil, err
+	}
+	h, _, err := newHandlerE(o)
+	if err != nil {
+		return nil, err
+	}
+	if o.Tracing {
+		h = NewTracingHandler(h)
+	}
+	return h, nil
+}
+
+// NewLogger creates the logger of the config, it must be closed to release its outputs.
+func (c *Config) NewLogger() (*Logger, error) {
+	o, err := c.options()
+	if err != nil {
+		return nil, err
+	}
+	return newLogger(o, true)
+}
+
+// options returns the options of the config.
+func (c *Config) options() (*options, error) {
+	o := newOptions()
+	WithLevel(c.Level)(o)
+	WithFormat(c.Format)(o)
+	WithOutput(c.Output)(o)
+	o.PackageLevels = c.PackageLevels
+	o.DisableSource = c.DisableSource
+	o.FullSource = c.FullSource
+	o.DisableTime = c.DisableTime
+	o.DisableColor = c.DisableColor
+	o.Tracing = c.Tracing
+
+	for _, sink := range c.Sinks {
+		WithSink(sink.Format, sink.Output, sink.Level)(o)
+	}
+	if c.Sampling != nil {
+		o.Sampling = &SamplingHandlerOptions{
+			Tick:       time.Duration(c.Sampling.Tick),
+			First:      c.Sampling.First,
+			Thereafter: c.Sampling.Thereafter,
+			KeyAttrs:   c.Sampling.KeyAttrs,
+		}
+	}
+	if c.Redact != nil {
+		redact, err := c.Redact.options()
+		if err != nil {
+			return nil, err
+		}
+		o.Redact = redact
+	}
+	return o, nil
+}
+
+func (c *RedactConfig) options() (*RedactHandlerOptions, error) {
+	o := &RedactHandlerOptions{Keys: c.Keys}
+	for _, pattern := range c.Patterns {
+		if builtin, ok := redactPatterns[pattern]; ok {
+			o.Patterns = append(o.Patterns, builtin...)
+			continue
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("redact pattern: %w", err)
+		}
+		o.Patterns = append(o.Patterns, re)
+	}
+
+	kind, arg, _ := strings.Cut(c.Replace, ":")
+	switch kind {
+	case "", "mask":
+		o.Replace = RedactMask
+	case "partial":
+		keep, err := strconv.Atoi(arg)
+		if err != nil || keep < 0 {
+			return nil, fmt.Errorf("invalid redact replace %q, want partial:N", c.Replace)
+		}
+		o.Replace = RedactPartial(keep)
+	case "hash":
+		o.Replace = RedactHash(arg)
+	default:
+		return nil, fmt.Errorf("unknown redact replace %q, want mask, partial:N or hash:SALT", c.Replace)
+	}
+	return o, nil
+}
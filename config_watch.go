@@ -0,0 +1,158 @@
+package slogx
+
+import (
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// WatchConfig creates the logger of the config file at path, it is reconfigured in place when the file changes,
+// the file is checked every interval, 1s when 0. A config which fails to load is logged and the logger keeps the previous one.
+// The loggers derived from the returned one, with With or WithGroup, are reconfigured too.
+// The logger must be closed to stop watching and release its outputs.
+func WatchConfig(path string, interval time.Duration) (*Logger, error) {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	c, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &configWatcher{
+		path:     path,
+		interval: interval,
+		levels:   &LevelController{},
+		modTime:  info.ModTime(),
+		size:     info.Size(),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	h, s, err := w.build(c)
+	if err != nil {
+		return nil, err
+	}
+	w.handler = NewSwappableHandler(h)
+	w.sinks = s
+
+	ws := &sinks{}
+	ws.add(w)
+	go w.run()
+	return &Logger{Logger: slog.New(w.handler), sinks: ws, levels: w.levels}, nil
+}
+
+// configWatcher reloads the config file when it changes.
+type configWatcher struct {
+	path     string
+	interval time.Duration
+	handler  *SwappableHandler
+	levels   *LevelController
+
+	mu sync.Mutex
+	// sinks are the outputs of the current config
+	sinks   *sinks
+	modTime time.Time
+	size    int64
+
+	done      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+}
+
+// build creates the handler of c, bound to the level controller of the watcher.
+func (w *configWatcher) build(c *Config) (slog.Handler, *sinks, error) {
+	o, err := c.options()
+	if err != nil {
+		return nil, nil, err
+	}
+	o.LevelController = w.levels
+	h, s, err := newHandlerE(o)
+	if err != nil {
+		return nil, nil, err
+	}
+	if o.Tracing {
+		h = NewTracingHandler(h)
+	}
+	return h, s, nil
+}
+
+func (w *configWatcher) run() {
+	defer close(w.stopped)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.check()
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// check reloads the config when the file changed since the last check.
+func (w *configWatcher) check() {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return
+	}
+	w.mu.Lock()
+	changed := !info.ModTime().Equal(w.modTime) || info.Size() != w.size
+	w.modTime, w.size = info.ModTime(), info.Size()
+	w.mu.Unlock()
+	if !changed {
+		return
+	}
+
+	logger := slog.New(w.handler)
+	if err := w.reload(); err != nil {
+		logger.Error("failed to reload log config, keeping the previous one", "path", w.path, "err", err)
+		return
+	}
+	logger.Info("log config reloaded", "path", w.path)
+}
+
+func (w *configWatcher) reload() error {
+	c, err := LoadConfig(w.path)
+	if err != nil {
+		return err
+	}
+	h, s, err := w.build(c)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	old := w.sinks
+	w.sinks = s
+	w.mu.Unlock()
+
+	// Swap returns once the records in flight on the old sinks are handled
+	w.handler.Swap(h)
+	return old.close()
+}
+
+// Sync flushes the outputs of the current config.
+func (w *configWatcher) Sync() error {
+	w.mu.Lock()
+	s := w.sinks
+	w.mu.Unlock()
+	return s.sync()
+}
+
+// Close stops watching and closes the outputs of the current config.
+func (w *configWatcher) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.done)
+		<-w.stopped
+	})
+	w.mu.Lock()
+	s := w.sinks
+	w.mu.Unlock()
+	return s.close()
+}
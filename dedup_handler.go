@@ -0,0 +1,179 @@
+package slogx
+
+import (
+	"context"
+	"hash/fnv"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// keys of the summary record of repeated records
+const (
+	RepeatedKey  = "repeated"
+	FirstSeenKey = "first_seen"
+	LastSeenKey  = "last_seen"
+)
+
+type DedupHandlerOptions struct {
+	// Window is the time repeats of a record are suppressed for, from its first occurrence.
+	// When 0, repeats are suppressed until a different record arrives.
+	Window time.Duration
+}
+
+// DedupHandler suppresses the repeats of a record, records with the same level, message and attrs.
+// They are summarized by a record with the repeated, first_seen and last_seen attrs,
+// when a different record arrives, the window closes or on Flush, which closing the logger of WithDedup calls.
+type DedupHandler struct {
+	state   *dedupState
+	handler slog.Handler
+	// prefix identifies the attrs and groups of the handler
+	prefix string
+}
+
+// dedupState is shared by a DedupHandler and the handlers derived from it.
+type dedupState struct {
+	opts DedupHandlerOptions
+
+	mu sync.Mutex
+	// last is the last handled record, its repeats are counted
+	last     *dedupEntry
+	lastHash uint64
+	timer    *time.Timer
+}
+
+type dedupEntry struct {
+	ctx      context.Context
+	handler  slog.Handler
+	record   slog.Record
+	repeated int
+	lastSeen time.Time
+	// written is closed once the record is handled, its summary is not written before it
+	written chan struct{}
+}
+
+func NewDedupHandler(handler slog.Handler, opts *DedupHandlerOptions) *DedupHandler {
+	state := &dedupState{}
+	if opts != nil {
+		state.opts = *opts
+	}
+	return &DedupHandler{state: state, handler: handler}
+}
+
+func (h *DedupHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return h.handler.Enabled(ctx, l)
+}
+
+func (h *DedupHandler) Handle(ctx context.Context, r slog.Record) error {
+	hash := h.hash(r)
+	s := h.state
+
+	s.mu.Lock()
+	if s.last != nil && s.lastHash == hash {
+		s.last.repeated++
+		s.last.lastSeen = r.Time
+		s.mu.Unlock()
+		return nil
+	}
+
+	prev := s.take()
+	entry := &dedupEntry{ctx: context.WithoutCancel(ctx), handler: h.handler, record: r.Clone(), lastSeen: r.Time, written: make(chan struct{})}
+	defer close(entry.written)
+	s.last = entry
+	s.lastHash = hash
+	if s.opts.Window > 0 {
+		last := s.last
+		s.timer = time.AfterFunc(s.opts.Window, func() {
+			s.mu.Lock()
+			// the window of another record may be open
+			if s.last != last {
+				s.mu.Unlock()
+				return
+			}
+			last := s.take()
+			s.mu.Unlock()
+			last.summarize()
+		})
+	}
+	s.mu.Unlock()
+
+	// the handler is called without the lock, a slow output does not serialize the records
+	err := prev.summarize()
+	if handleErr := h.handler.Handle(ctx, r); err == nil {
+		err = handleErr
+	}
+	return err
+}
+
+// take forgets the last record and returns it, s.mu is held.
+func (s *dedupState) take() *dedupEntry {
+	last := s.last
+	s.last = nil
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	return last
+}
+
+// summarize handles the summary of e when it was repeated, e can be nil.
+func (e *dedupEntry) summarize() error {
+	if e == nil || e.repeated == 0 {
+		return nil
+	}
+	<-e.written
+	r := slog.NewRecord(e.lastSeen, e.record.Level, e.record.Message, e.record.PC)
+	e.record.Attrs(func(attr slog.Attr) bool {
+		r.AddAttrs(attr)
+		return true
+	})
+	r.AddAttrs(
+		slog.Int(RepeatedKey, e.repeated),
+		slog.Time(FirstSeenKey, e.record.Time),
+		slog.Time(LastSeenKey, e.lastSeen),
+	)
+	return e.handler.Handle(e.ctx, r)
+}
+
+// Flush handles the summary of the pending repeats.
+func (h *DedupHandler) Flush() error {
+	h.state.mu.Lock()
+	last := h.state.take()
+	h.state.mu.Unlock()
+	return last.summarize()
+}
+
+// hash hashes the level, the message and the attrs of r, with the attrs of the handler.
+func (h *DedupHandler) hash(r slog.Record) uint64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(h.prefix))
+	hash.Write([]byte(r.Level.String()))
+	hash.Write([]byte{0})
+	hash.Write([]byte(r.Message))
+	r.Attrs(func(attr slog.Attr) bool {
+		hash.Write([]byte{0})
+		hash.Write([]byte(attr.String()))
+		return true
+	})
+	return hash.Sum64()
+}
+
+func (h *DedupHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	prefix := h.prefix
+	for _, attr := range attrs {
+		prefix += attr.String() + "\x00"
+	}
+	return &DedupHandler{state: h.state, handler: h.handler.WithAttrs(attrs), prefix: prefix}
+}
+
+func (h *DedupHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &DedupHandler{state: h.state, handler: h.handler.WithGroup(name), prefix: h.prefix + name + ".\x00"}
+}
+
+var _ slog.Handler = (*DedupHandler)(nil)
@@ -0,0 +1,154 @@
+package slogx
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ECSVersion is the Elastic Common Schema version written to ecs.version.
+const ECSVersion = "8.11.0"
+
+// ECSHandler writes records as Elastic Common Schema json,
+// see https://www.elastic.co/guide/en/ecs-logging/overview/current/intro.html
+//
+// The built-in keys become @timestamp, log.level, message and log.origin,
+// the first top level err or error attr holding an error becomes the error field set,
+// the other errors stay under their key, or err when it is error,
+// and the trace.id and span.id of the span in the context are added.
+type ECSHandler struct {
+	// handler is a json handler holding the top level attrs
+	handler slog.Handler
+
+	// the ecs fields are top level so groups are applied by the ECSHandler itself
+	groups groupState
+	// errorTaken is set when the top level attrs of the handler have an error key
+	errorTaken bool
+}
+
+func NewECSHandler(w io.Writer, opts *slog.HandlerOptions) *ECSHandler {
+	var ho slog.HandlerOptions
+	if opts != nil {
+		ho = *opts
+	}
+	replace := ho.ReplaceAttr
+	ho.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+		if replace != nil {
+			a = replace(groups, a)
+		}
+		return ecsReplaceAttr(groups, a)
+	}
+	return &ECSHandler{handler: slog.NewJSONHandler(w, &ho)}
+}
+
+func (h *ECSHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return h.handler.Enabled(ctx, l)
+}
+
+func (h *ECSHandler) Handle(ctx context.Context, r slog.Record) error {
+	attrs := h.groups.nest(r)
+	if h.groups.empty() {
+		attrs, _ = ecsErrors(attrs, h.errorTaken)
+	}
+
+	nr := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	nr.AddAttrs(slog.String("ecs.version", ECSVersion))
+	if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.IsValid() {
+		nr.AddAttrs(slog.String("trace.id", spanCtx.TraceID().String()),
+			slog.String("span.id", spanCtx.SpanID().String()))
+	}
+	nr.AddAttrs(attrs...)
+	return h.handler.Handle(ctx, nr)
+}
+
+func (h *ECSHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	if h.groups.empty() {
+		attrs, taken := ecsErrors(append([]slog.Attr(nil), attrs...), h.errorTaken)
+		return &ECSHandler{handler: h.handler.WithAttrs(attrs), errorTaken: taken}
+	}
+	return &ECSHandler{handler: h.handler, groups: h.groups.withAttrs(attrs), errorTaken: h.errorTaken}
+}
+
+func (h *ECSHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &ECSHandler{handler: h.handler, groups: h.groups.withGroup(name), errorTaken: h.errorTaken}
+}
+
+// ecsErrors maps the first err or error attr holding an error to the error field set, in place,
+// taken is set when the error key is already used. The error key must appear once,
+// so a later error attr is renamed err.
+func ecsErrors(attrs []slog.Attr, taken bool) ([]slog.Attr, bool) {
+	for i, a := range attrs {
+		if a.Key != "err" && a.Key != "error" {
+			continue
+		}
+		if taken {
+			if a.Key == "error" {
+				attrs[i].Key = "err"
+			}
+			continue
+		}
+		if err, ok := a.Value.Resolve().Any().(error); ok {
+			attrs[i] = slog.Attr{Key: "error", Value: ecsError(err)}
+			taken = true
+		} else if a.Key == "error" {
+			taken = true
+		}
+	}
+	return attrs, taken
+}
+
+// ecsReplaceAttr maps the built-in keys to ecs fields.
+func ecsReplaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) > 0 || a.Key == "" {
+		return a
+	}
+
+	switch a.Key {
+	case slog.TimeKey:
+		return slog.Attr{Key: "@timestamp", Value: a.Value}
+	case slog.LevelKey:
+		return slog.String("log.level", strings.ToLower(a.Value.String()))
+	case slog.MessageKey:
+		return slog.Attr{Key: "message", Value: a.Value}
+	case slog.SourceKey:
+		return ecsOrigin(a.Value)
+	}
+	return a
+}
+
+// ecsOrigin returns the log.origin field set of a source attr value.
+func ecsOrigin(v slog.Value) slog.Attr {
+	src := sourceFromValue(v)
+	attrs := []slog.Attr{slog.String("file.name", src.File)}
+	if src.Line > 0 {
+		attrs = append(attrs, slog.Int("file.line", src.Line))
+	}
+	if src.Function != "" {
+		attrs = append(attrs, slog.String("function", src.Function))
+	}
+	return slog.Attr{Key: "log.origin", Value: slog.GroupValue(attrs...)}
+}
+
+func ecsError(err error) slog.Value {
+	attrs := []slog.Attr{
+		slog.String("message", err.Error()),
+		slog.String("type", fmt.Sprintf("%T", err)),
+	}
+	// errors with a stack trace, like github.com/pkg/errors, print it with %+v
+	if stack := fmt.Sprintf("%+v", err); stack != err.Error() {
+		attrs = append(attrs, slog.String("stack_trace", stack))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+var _ slog.Handler = (*ECSHandler)(nil)
@@ -0,0 +1,68 @@
+package slogx
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DefaultEnvPrefix is the prefix of the environment variables read by InitDefault.
+const DefaultEnvPrefix = "SLOGX"
+
+// WithEnv reads the options from the environment variables named after prefix, SLOGX by default:
+// SLOGX_LEVEL, SLOGX_FORMAT, SLOGX_OUTPUT, SLOGX_DISABLE_SOURCE, SLOGX_FULL_SOURCE,
+// SLOGX_DISABLE_TIME, SLOGX_NO_COLOR and SLOGX_TRACING.
+// The other options take precedence over the environment, whatever their order.
+func WithEnv(prefix string) Option {
+	if prefix == "" {
+		prefix = DefaultEnvPrefix
+	}
+	return func(o *options) { o.envPrefix = prefix }
+}
+
+// envPrefix returns the prefix of the WithEnv option in opts.
+func envPrefix(opts []Option) string {
+	scratch := &options{}
+	for _, o := range opts {
+		o(scratch)
+	}
+	return scratch.envPrefix
+}
+
+// applyEnv sets the options from the environment variables, the invalid ones are returned as error.
+func (o *options) applyEnv(prefix string) error {
+	prefix = strings.TrimSuffix(prefix, "_") + "_"
+
+	if v, ok := os.LookupEnv(prefix + "LEVEL"); ok && v != "" {
+		o.Level = strings.ToLower(v)
+	}
+	if v, ok := os.LookupEnv(prefix + "FORMAT"); ok && v != "" {
+		o.Format = strings.ToLower(v)
+	}
+	if v, ok := os.LookupEnv(prefix + "OUTPUT"); ok && v != "" {
+		o.Output = v
+	}
+
+	var errs []error
+	for name, field := range map[string]*bool{
+		"DISABLE_SOURCE": &o.DisableSource,
+		"FULL_SOURCE":    &o.FullSource,
+		"DISABLE_TIME":   &o.DisableTime,
+		"NO_COLOR":       &o.DisableColor,
+		"TRACING":        &o.Tracing,
+	} {
+		v, ok := os.LookupEnv(prefix + name)
+		if !ok || v == "" {
+			continue
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s%s %q, want a boolean", prefix, name, v))
+			continue
+		}
+		*field = b
+	}
+	return errors.Join(errs...)
+}
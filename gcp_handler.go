@@ -0,0 +1,231 @@
+package slogx
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Google Cloud Logging special json fields,
+// see https://cloud.google.com/logging/docs/structured-logging
+const (
+	GCPSourceLocationKey = "logging.googleapis.com/sourceLocation"
+	GCPTraceKey          = "logging.googleapis.com/trace"
+	GCPSpanIDKey         = "logging.googleapis.com/spanId"
+	GCPTraceSampledKey   = "logging.googleapis.com/trace_sampled"
+	GCPLabelsKey         = "logging.googleapis.com/labels"
+	GCPHTTPRequestKey    = "httpRequest"
+)
+
+// GCPHandler writes records as Google Cloud Logging structured json.
+//
+// The level becomes severity, the message message and the source sourceLocation,
+// the span in the context sets trace and spanId, the attrs of the labels group become labels,
+// and a top level *http.Request or HTTPRequest attr becomes httpRequest.
+type GCPHandler struct {
+	handler slog.Handler
+	opts    *GCPHandlerOptions
+
+	// the special fields are top level so groups are applied by the GCPHandler itself
+	groups groupState
+}
+
+type GCPHandlerOptions struct {
+	// ProjectID is used to write the trace as projects/[ProjectID]/traces/[TRACE_ID],
+	// the trace id is written alone when empty.
+	ProjectID string
+	// LabelsGroup is the top level group written as labels, default to "labels".
+	LabelsGroup string
+	slog.HandlerOptions
+}
+
+// HTTPRequest is logged as the httpRequest field by the GCPHandler.
+type HTTPRequest struct {
+	Request      *http.Request
+	Status       int
+	ResponseSize int64
+	Latency      time.Duration
+}
+
+func NewGCPHandler(w io.Writer, opts *GCPHandlerOptions) *GCPHandler {
+	if opts == nil {
+		opts = &GCPHandlerOptions{}
+	}
+	ho := opts.HandlerOptions
+	replace := ho.ReplaceAttr
+	ho.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+		if replace != nil {
+			a = replace(groups, a)
+		}
+		return gcpReplaceAttr(groups, a)
+	}
+	return &GCPHandler{handler: slog.NewJSONHandler(w, &ho), opts: opts}
+}
+
+func (h *GCPHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return h.handler.Enabled(ctx, l)
+}
+
+func (h *GCPHandler) Handle(ctx context.Context, r slog.Record) error {
+	attrs := h.groups.nest(r)
+
+	nr := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.IsValid() {
+		traceID := spanCtx.TraceID().String()
+		if h.opts.ProjectID != "" {
+			traceID = "projects/" + h.opts.ProjectID + "/traces/" + traceID
+		}
+		nr.AddAttrs(slog.String(GCPTraceKey, traceID),
+			slog.String(GCPSpanIDKey, spanCtx.SpanID().String()),
+			slog.Bool(GCPTraceSampledKey, spanCtx.IsSampled()))
+	}
+	for _, attr := range attrs {
+		nr.AddAttrs(h.labels(attr))
+	}
+	return h.handler.Handle(ctx, nr)
+}
+
+// labels turns the labels group into the labels field, label values must be strings.
+func (h *GCPHandler) labels(attr slog.Attr) slog.Attr {
+	labelsGroup := h.opts.LabelsGroup
+	if labelsGroup == "" {
+		labelsGroup = "labels"
+	}
+	if attr.Key != labelsGroup || attr.Value.Kind() != slog.KindGroup {
+		return attr
+	}
+
+	labels := make([]slog.Attr, 0, len(attr.Value.Group()))
+	for _, label := range attr.Value.Group() {
+		labels = append(labels, slog.String(label.Key, label.Value.Resolve().String()))
+	}
+	return slog.Attr{Key: GCPLabelsKey, Value: slog.GroupValue(labels...)}
+}
+
+func (h *GCPHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	if h.groups.empty() {
+		labeled := make([]slog.Attr, len(attrs))
+		for i, attr := range attrs {
+			labeled[i] = h.labels(attr)
+		}
+		return &GCPHandler{handler: h.handler.WithAttrs(labeled), opts: h.opts}
+	}
+	return &GCPHandler{handler: h.handler, opts: h.opts, groups: h.groups.withAttrs(attrs)}
+}
+
+func (h *GCPHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &GCPHandler{handler: h.handler, opts: h.opts, groups: h.groups.withGroup(name)}
+}
+
+// gcpReplaceAttr maps the built-in keys and the top level http requests to the special fields.
+func gcpReplaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) > 0 || a.Key == "" {
+		return a
+	}
+
+	switch a.Key {
+	case slog.LevelKey:
+		if l, ok := a.Value.Any().(slog.Level); ok {
+			return slog.String("severity", GCPSeverity(l))
+		}
+		// a level already replaced by its name
+		if l, err := ParseLevel(a.Value.String()); err == nil {
+			return slog.String("severity", GCPSeverity(l))
+		}
+		return slog.Attr{Key: "severity", Value: a.Value}
+	case slog.MessageKey:
+		return slog.Attr{Key: "message", Value: a.Value}
+	case slog.SourceKey:
+		src := sourceFromValue(a.Value)
+		attrs := []slog.Attr{slog.String("file", src.File)}
+		if src.Line > 0 {
+			// the line is an int64, which is a string in the json of the api
+			attrs = append(attrs, slog.String("line", strconv.Itoa(src.Line)))
+		}
+		if src.Function != "" {
+			attrs = append(attrs, slog.String("function", src.Function))
+		}
+		return slog.Attr{Key: GCPSourceLocationKey, Value: slog.GroupValue(attrs...)}
+	}
+
+	switch v := a.Value.Any().(type) {
+	case *http.Request:
+		return slog.Attr{Key: GCPHTTPRequestKey, Value: gcpHTTPRequest(HTTPRequest{Request: v})}
+	case HTTPRequest:
+		return slog.Attr{Key: GCPHTTPRequestKey, Value: gcpHTTPRequest(v)}
+	case *HTTPRequest:
+		return slog.Attr{Key: GCPHTTPRequestKey, Value: gcpHTTPRequest(*v)}
+	}
+	return a
+}
+
+// GCPSeverity maps a level to a Cloud Logging severity,
+// the levels between two severities map to the lower one.
+func GCPSeverity(l slog.Level) string {
+	switch {
+	case l < slog.LevelInfo:
+		return "DEBUG"
+	case l < slog.LevelInfo+2:
+		return "INFO"
+	case l < slog.LevelWarn:
+		return "NOTICE"
+	case l < slog.LevelError:
+		return "WARNING"
+	case l < slog.LevelError+4:
+		return "ERROR"
+	case l < slog.LevelError+8:
+		return "CRITICAL"
+	case l < slog.LevelError+12:
+		return "ALERT"
+	default:
+		return "EMERGENCY"
+	}
+}
+
+// gcpHTTPRequest returns the HttpRequest field of req,
+// see https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry#HttpRequest
+func gcpHTTPRequest(req HTTPRequest) slog.Value {
+	var attrs []slog.Attr
+	if r := req.Request; r != nil {
+		attrs = append(attrs,
+			slog.String("requestMethod", r.Method),
+			slog.String("requestUrl", r.URL.String()),
+			slog.String("protocol", r.Proto))
+		if r.ContentLength > 0 {
+			attrs = append(attrs, slog.String("requestSize", strconv.FormatInt(r.ContentLength, 10)))
+		}
+		if ua := r.UserAgent(); ua != "" {
+			attrs = append(attrs, slog.String("userAgent", ua))
+		}
+		if referer := r.Referer(); referer != "" {
+			attrs = append(attrs, slog.String("referer", referer))
+		}
+		if r.RemoteAddr != "" {
+			attrs = append(attrs, slog.String("remoteIp", r.RemoteAddr))
+		}
+	}
+	if req.Status != 0 {
+		attrs = append(attrs, slog.Int("status", req.Status))
+	}
+	if req.ResponseSize != 0 {
+		attrs = append(attrs, slog.String("responseSize", strconv.FormatInt(req.ResponseSize, 10)))
+	}
+	if req.Latency != 0 {
+		attrs = append(attrs, slog.String("latency", fmt.Sprintf("%.9fs", req.Latency.Seconds())))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+var _ slog.Handler = (*GCPHandler)(nil)
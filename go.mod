@@ -1,16 +1,20 @@
 module github.com/ttys3/slogx
 
-go 1.21
+// go.opentelemetry.io/otel v1.32.0 requires go 1.22
+go 1.22
+
 toolchain go1.22.5
 
 require (
+	github.com/BurntSushi/toml v1.4.0
 	github.com/fatih/color v1.18.0
 	go.opentelemetry.io/otel v1.32.0
 	go.opentelemetry.io/otel/trace v1.32.0
+	golang.org/x/sys v0.25.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
-	golang.org/x/sys v0.25.0 // indirect
 )
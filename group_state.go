@@ -0,0 +1,44 @@
+package slogx
+
+import (
+	"log/slog"
+)
+
+// groupState holds the groups opened by WithGroup and the attrs added in each of them,
+// for handlers wrapping a json handler which need to add top level attrs to records.
+type groupState struct {
+	groups     []string
+	groupAttrs [][]slog.Attr
+}
+
+func (g groupState) empty() bool {
+	return len(g.groups) == 0
+}
+
+func (g groupState) withGroup(name string) groupState {
+	return groupState{
+		groups:     append(g.groups[:len(g.groups):len(g.groups)], name),
+		groupAttrs: append(g.groupAttrs[:len(g.groupAttrs):len(g.groupAttrs)], nil),
+	}
+}
+
+func (g groupState) withAttrs(attrs []slog.Attr) groupState {
+	groupAttrs := append([][]slog.Attr(nil), g.groupAttrs...)
+	last := len(groupAttrs) - 1
+	groupAttrs[last] = append(groupAttrs[last][:len(groupAttrs[last]):len(groupAttrs[last])], attrs...)
+	return groupState{groups: g.groups, groupAttrs: groupAttrs}
+}
+
+// nest returns the attrs of r nested in the groups.
+func (g groupState) nest(r slog.Record) []slog.Attr {
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, attr)
+		return true
+	})
+	for i := len(g.groups) - 1; i >= 0; i-- {
+		groupAttrs := append(g.groupAttrs[i][:len(g.groupAttrs[i]):len(g.groupAttrs[i])], attrs...)
+		attrs = []slog.Attr{{Key: g.groups[i], Value: slog.GroupValue(groupAttrs...)}}
+	}
+	return attrs
+}
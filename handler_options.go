@@ -4,16 +4,28 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"math"
 	"os"
+	"strconv"
 	"strings"
 )
 
-func InitDefault() {
-	slog.SetDefault(slog.New(NewTracingHandler(NewHandler(&options{}))))
+// InitDefault sets the default logger, with tracing,
+// configured by the SLOGX_* environment variables and by opts, see WithEnv.
+func InitDefault(opts ...Option) {
+	opts = append([]Option{WithEnv(DefaultEnvPrefix)}, opts...)
+	options := newOptions(opts...)
+	h, _ := newHandler(options)
+	slog.SetDefault(slog.New(NewTracingHandler(h)))
 }
 
-// New create a new *slog.Logger with tracing handler wrapper
+// New create a new *slog.Logger with tracing handler wrapper,
+// its outputs are closed by Shutdown, use NewLogger to close them with the logger.
 func New(opts ...Option) *slog.Logger {
+	return NewLogger(opts...).Logger
+}
+
+func newOptions(opts ...Option) *options {
 	options := &options{
 		Options: Options{
 			DisableSource: false,
@@ -24,63 +36,273 @@ func New(opts ...Option) *slog.Logger {
 		Format: "json",
 		Output: "stderr",
 	}
+	// the environment is read first, the options take precedence
+	if prefix := envPrefix(opts); prefix != "" {
+		options.envErr = options.applyEnv(prefix)
+	}
 	for _, o := range opts {
 		o(options)
 	}
+	return options
+}
+
+// NewE is like New, it returns an error for invalid options instead of falling back to defaults.
+func NewE(opts ...Option) (*slog.Logger, error) {
+	options := newOptions(opts...)
 
-	h := NewHandler(options)
+	h, _, err := newHandlerE(options)
+	if err != nil {
+		return nil, err
+	}
 	if options.Tracing {
 		h = NewTracingHandler(h)
 	}
-	return slog.New(h)
+	return slog.New(h), nil
 }
 
-func NewHandler(options *options) slog.Handler {
-	var w io.Writer
-	if options.Writer != nil {
-		w = options.Writer
-	} else {
-		switch options.Output {
-		case "stdout":
-			w = os.Stdout
-		case "stderr", "":
-			w = os.Stderr
-		case "discard":
-			w = io.Discard
-		default:
-			var err error
-			w, err = os.OpenFile(options.Output, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+// NewHandler creates the handler of opts, without tracing, its outputs are closed by Shutdown.
+// Invalid options fall back to their default, and an output which fails to open to stderr.
+// See also Config.NewHandler.
+func NewHandler(opts ...Option) slog.Handler {
+	h, _ := newHandler(newOptions(opts...))
+	return h
+}
+
+// NewHandlerE creates the handler of opts, without tracing, its outputs are closed by Shutdown.
+// It returns an error for invalid options or an output which fails to open.
+func NewHandlerE(opts ...Option) (slog.Handler, error) {
+	h, _, err := newHandlerE(newOptions(opts...))
+	return h, err
+}
+
+// newHandler creates the handler of options and returns the outputs it opened.
+func newHandler(options *options) (slog.Handler, *sinks) {
+	s := &sinks{}
+	h, _ := buildHandler(options, s, false)
+	return h, s
+}
+
+func newHandlerE(options *options) (slog.Handler, *sinks, error) {
+	s := &sinks{}
+	h, err := buildHandler(options, s, true)
+	if err != nil {
+		s.close()
+		return nil, nil, err
+	}
+	return h, s, nil
+}
+
+// buildHandler creates the handler of options and of its sinks, the outputs it opens are added to s.
+// When strict, invalid options and outputs which fail to open are errors, otherwise they fall back to defaults.
+func buildHandler(options *options, s *sinks, strict bool) (slog.Handler, error) {
+	if strict {
+		if err := options.validate(); err != nil {
+			return nil, err
+		}
+	}
+	level := parseLevel(options.Level)
+	var packageLevels *PackageLevels
+	if options.PackageLevels != "" {
+		var err error
+		if packageLevels, err = ParsePackageLevels(options.PackageLevels, level.Level()); err != nil {
+			slog.Error("invalid package levels, ignored", "err", err)
+		} else {
+			// the records are filtered by the PackageLevelHandler
+			level.Set(slog.Level(math.MinInt))
+		}
+	}
+	opts := NewHandlerOptions(level, &options.Options)
+
+	h, w, err := openOutput(options, opts, s)
+	if err != nil {
+		if strict {
+			return nil, err
+		}
+		slog.Error("failed to open log output, fallback to stderr", "output", options.Output, "err", err)
+		w = os.Stderr
+	}
+	var asyncOpts AsyncHandlerOptions
+	if options.Async != nil {
+		asyncOpts = *options.Async
+	}
+	if h == nil {
+		if options.Async != nil {
+			asyncOpts.BatchWriter = NewBatchWriter(w)
+			w = asyncOpts.BatchWriter
+		}
+		h = newWriterHandler(w, opts, options)
+	}
+	if options.Async != nil {
+		ah := NewAsyncHandler(h, &asyncOpts)
+		// added after the output, so it is drained before the output is closed
+		s.add(asyncSink{ah})
+		h = ah
+	}
+	// the level changes are logged to the output without the filters below,
+	// they must not be dropped by the package levels, the sampling or the dedup
+	output := h
+	// filtered before they are queued
+	var packageHandler *PackageLevelHandler
+	if packageLevels != nil {
+		packageHandler = NewPackageLevelHandler(h, packageLevels)
+		h = packageHandler
+	}
+	if len(options.Sinks) > 0 {
+		handlers := []slog.Handler{h}
+		for _, sink := range options.Sinks {
+			sinkOptions := sink.options(options)
+			sh, err := buildHandler(sinkOptions, s, strict)
 			if err != nil {
-				slog.Error("failed to open log file, fallback to stderr", err)
-				w = os.Stderr
+				return nil, fmt.Errorf("sink %s: %w", sinkOptions.Output, err)
 			}
+			handlers = append(handlers, sh)
 		}
+		h = Multi(handlers...)
+	}
+	// sampled out records are dropped before they are queued or formatted
+	if options.Sampling != nil {
+		h = NewSamplingHandler(h, options.Sampling)
+	}
+	if options.Dedup != nil {
+		dh := NewDedupHandler(h, options.Dedup)
+		// added after the outputs, so the pending summary is written before they are closed
+		s.add(dedupSink{dh})
+		h = dh
+	}
+	// even the sampling hook only sees redacted records
+	if options.Redact != nil {
+		h = NewRedactHandler(h, options.Redact)
 	}
 
-	var theLevel slog.Level
-	switch options.Level {
-	case "debug":
-		theLevel = slog.LevelDebug
-	case "info":
-		theLevel = slog.LevelInfo
-	case "warn":
-		theLevel = slog.LevelWarn
-	case "error":
-		theLevel = slog.LevelError
-	default:
-		theLevel = slog.LevelInfo
+	if options.LevelSignals && options.LevelController == nil {
+		options.LevelController = &LevelController{}
+	}
+	if options.LevelController != nil {
+		options.LevelController.bind(level, packageHandler, output)
 	}
+	if options.LevelSignals {
+		s.add(closerFunc(options.LevelController.NotifySignals(defaultLevelSignals())))
+	}
+	return h, nil
+}
 
-	lvl := &slog.LevelVar{}
-	lvl.Set(theLevel)
+// openOutput opens the Output, it returns the handler of syslog and journald outputs, the writer of others.
+// The Writer overrides the Output.
+func openOutput(options *options, opts slog.HandlerOptions, s *sinks) (slog.Handler, io.Writer, error) {
+	if options.Writer != nil {
+		return nil, options.Writer, nil
+	}
+	switch {
+	case isSyslogOutput(options.Output):
+		sh, sw, err := NewSyslogHandlerFromURL(options.Output, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		s.add(sw)
+		return sh, nil, nil
+	case isJournaldOutput(options.Output):
+		socket := strings.TrimPrefix(options.Output, "journald://")
+		if socket == "journald" {
+			socket = ""
+		}
+		jh := NewJournaldHandler(&JournaldHandlerOptions{SocketPath: socket, HandlerOptions: opts})
+		s.add(jh)
+		return jh, nil, nil
+	}
+
+	switch options.Output {
+	case "stdout":
+		return nil, os.Stdout, nil
+	case "stderr", "":
+		return nil, os.Stderr, nil
+	case "discard":
+		return nil, io.Discard, nil
+	}
+	f, err := openFile(options, s)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open log file: %w", err)
+	}
+	return nil, f, nil
+}
+
+func isSyslogOutput(output string) bool {
+	return strings.HasPrefix(output, "syslog+")
+}
+
+func isJournaldOutput(output string) bool {
+	return output == "journald" || strings.HasPrefix(output, "journald://")
+}
+
+// isFileOutput reports whether options write to the Output file.
+func isFileOutput(options *options) bool {
+	if isSyslogOutput(options.Output) || isJournaldOutput(options.Output) || options.Writer != nil {
+		return false
+	}
+	switch options.Output {
+	case "stdout", "stderr", "", "discard":
+		return false
+	}
+	return true
+}
+
+// newWriterHandler creates the Format handler writing to w.
+func newWriterHandler(w io.Writer, opts slog.HandlerOptions, options *options) slog.Handler {
+	if options.PriorityPrefix {
+		return NewPriorityPrefixHandler(w, func(w io.Writer) slog.Handler {
+			return newFormatHandler(w, opts, options)
+		})
+	}
+	return newFormatHandler(w, opts, options)
+}
 
-	opts := NewHandlerOptions(lvl, &options.Options)
+// openFile opens the Output file, it can be reopened with ReopenFiles or on ReopenSignals.
+func openFile(options *options, s *sinks) (io.Writer, error) {
+	var f interface {
+		io.WriteCloser
+		Reopener
+	}
+	var err error
+	if options.Rotation != nil {
+		f, err = NewRotatingFile(options.Output, *options.Rotation)
+	} else {
+		f, err = OpenReopenableFile(options.Output)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	registerFile(f)
+	s.add(f)
+	if options.ReopenSignals != nil {
+		// added after the file, so it is stopped before the file is closed
+		s.add(closerFunc(NotifyReopen(f, options.ReopenSignals...)))
+	}
+	return f, nil
+}
+
+func newFormatHandler(w io.Writer, opts slog.HandlerOptions, options *options) slog.Handler {
 	var th slog.Handler
 	switch options.Format {
 	case "text":
 		th = slog.NewTextHandler(w, &opts)
 	case "cli":
-		th = NewCliHandler(w, &CliHandlerOptions{DisableColor: options.DisableColor, HandlerOptions: opts})
+		cliOpts := &CliHandlerOptions{
+			DisableColor:   options.DisableColor,
+			TagKey:         options.TagKey,
+			TagFromGroup:   options.TagFromGroup,
+			TagWidth:       options.TagWidth,
+			CollapseAttrs:  options.CollapseAttrs,
+			HandlerOptions: opts,
+		}
+		th = NewCliHandler(w, cliOpts)
+	case "logfmt":
+		th = NewLogfmtHandler(w, &LogfmtHandlerOptions{HandlerOptions: opts})
+	case "ecs":
+		th = NewECSHandler(w, &opts)
+	case "gcp":
+		th = NewGCPHandler(w, &GCPHandlerOptions{HandlerOptions: opts})
+	case "pretty-json":
+		th = NewPrettyJSONHandler(w, &PrettyJSONHandlerOptions{DisableColor: options.DisableColor, HandlerOptions: opts})
 	case "json":
 		fallthrough
 	default:
@@ -89,16 +311,31 @@ func NewHandler(options *options) slog.Handler {
 	return th
 }
 
+func parseLevel(level string) *slog.LevelVar {
+	theLevel, err := parseLevelE(level)
+	if err != nil {
+		theLevel = slog.LevelInfo
+	}
+
+	lvl := &slog.LevelVar{}
+	lvl.Set(theLevel)
+	return lvl
+}
+
+// parseLevelE parses a level with ParseLevel, empty is info.
+func parseLevelE(level string) (slog.Level, error) {
+	if level == "" {
+		return slog.LevelInfo, nil
+	}
+	return ParseLevel(level)
+}
+
 func NewHandlerOptions(level slog.Leveler, opt *Options) slog.HandlerOptions {
 	ho := slog.HandlerOptions{
 		AddSource: !opt.DisableSource,
 		Level:     level,
 	}
 
-	if !opt.DisableTime && (opt.FullSource || opt.DisableSource) {
-		return ho
-	}
-
 	ho.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
 		if opt.DisableTime {
 			if a.Key == slog.TimeKey {
@@ -113,6 +350,9 @@ func NewHandlerOptions(level slog.Leveler, opt *Options) slog.HandlerOptions {
 			if !opt.DisableSource && !opt.FullSource {
 				return handleSourceKey(a)
 			}
+		case slog.LevelKey:
+			// the names of the custom levels
+			return ReplaceLevelAttr(groups, a)
 		}
 
 		return a
@@ -153,3 +393,19 @@ func handleSourceKey(a slog.Attr) slog.Attr {
 		Value: slog.StringValue(file),
 	}
 }
+
+// sourceFromValue returns the source of a source attr value,
+// it can be a *slog.Source or a file:line string made by handleSourceKey.
+func sourceFromValue(v slog.Value) *slog.Source {
+	if src, ok := v.Any().(*slog.Source); ok {
+		return src
+	}
+
+	file := v.String()
+	if idx := strings.LastIndexByte(file, ':'); idx > 0 {
+		if line, err := strconv.Atoi(file[idx+1:]); err == nil {
+			return &slog.Source{File: file[:idx], Line: line}
+		}
+	}
+	return &slog.Source{File: file}
+}
@@ -24,6 +24,11 @@ func (b *Buffer) Free() {
 		bufPool.Put(b)
 	}
 }
+
+func (b *Buffer) Reset() {
+	*b = (*b)[:0]
+}
+
 func (b *Buffer) Write(bytes []byte) (int, error) {
 	*b = append(*b, bytes...)
 	return len(bytes), nil
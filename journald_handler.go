@@ -0,0 +1,283 @@
+package slogx
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/ttys3/slogx/internal"
+)
+
+// DefaultJournaldSocket is the socket of the native journal protocol.
+const DefaultJournaldSocket = "/run/systemd/journal/socket"
+
+// JournaldHandler sends records to systemd-journald with the native journal protocol,
+// see https://systemd.io/JOURNAL_NATIVE_PROTOCOL/
+//
+// The record becomes the MESSAGE, PRIORITY and CODE_* fields,
+// attrs become fields named after their upper-cased dotted keys.
+// Entries too large for a datagram are sent in a sealed memfd.
+type JournaldHandler struct {
+	conn *journaldConn
+	opts *JournaldHandlerOptions
+
+	// fields holds the attrs added by WithAttrs, already serialized
+	fields      []byte
+	groupPrefix string
+	groups      []string
+}
+
+type JournaldHandlerOptions struct {
+	// SocketPath default to DefaultJournaldSocket.
+	SocketPath string
+	// Identifier is the SYSLOG_IDENTIFIER field, default to the program name.
+	Identifier string
+	slog.HandlerOptions
+}
+
+// journaldConn is shared by a JournaldHandler and the handlers derived from it.
+type journaldConn struct {
+	mu   sync.Mutex
+	path string
+	conn *net.UnixConn
+}
+
+func NewJournaldHandler(opts *JournaldHandlerOptions) *JournaldHandler {
+	if opts == nil {
+		opts = &JournaldHandlerOptions{}
+	}
+	path := opts.SocketPath
+	if path == "" {
+		path = DefaultJournaldSocket
+	}
+	return &JournaldHandler{conn: &journaldConn{path: path}, opts: opts}
+}
+
+func (h *JournaldHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.opts.Level != nil {
+		minLevel = h.opts.Level.Level()
+	}
+	return l >= minLevel
+}
+
+func (h *JournaldHandler) Handle(ctx context.Context, r slog.Record) error {
+	buf := internal.NewBuffer()
+	defer buf.Free()
+
+	appendJournaldField(buf, "MESSAGE", r.Message)
+	appendJournaldField(buf, "PRIORITY", strconv.Itoa(SyslogSeverity(r.Level)))
+	identifier := h.opts.Identifier
+	if identifier == "" {
+		identifier = filepath.Base(os.Args[0])
+	}
+	appendJournaldField(buf, "SYSLOG_IDENTIFIER", identifier)
+	if h.opts.AddSource && r.PC != 0 {
+		src := recordSource(r)
+		appendJournaldField(buf, "CODE_FILE", src.File)
+		appendJournaldField(buf, "CODE_LINE", strconv.Itoa(src.Line))
+		appendJournaldField(buf, "CODE_FUNC", src.Function)
+	}
+
+	buf.Write(h.fields)
+	r.Attrs(func(attr slog.Attr) bool {
+		h.appendAttr(buf, attr)
+		return true
+	})
+
+	return h.conn.send(buf.Bytes())
+}
+
+func (h *JournaldHandler) appendAttr(buf *internal.Buffer, attr slog.Attr) {
+	walkAttr(attr, h.groupPrefix, h.groups, h.opts.ReplaceAttr, func(key string, v slog.Value) {
+		appendJournaldField(buf, JournaldFieldName(key), logfmtValueString(v))
+	})
+}
+
+func (h *JournaldHandler) clone() *JournaldHandler {
+	return &JournaldHandler{
+		conn:        h.conn,
+		opts:        h.opts,
+		fields:      append([]byte(nil), h.fields...),
+		groupPrefix: h.groupPrefix,
+		groups:      append([]string(nil), h.groups...),
+	}
+}
+
+func (h *JournaldHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	cloned := h.clone()
+	buf := internal.NewBuffer()
+	defer buf.Free()
+	for _, attr := range attrs {
+		h.appendAttr(buf, attr)
+	}
+	cloned.fields = append(cloned.fields, buf.Bytes()...)
+	return cloned
+}
+
+func (h *JournaldHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	cloned := h.clone()
+	cloned.groupPrefix += name + "."
+	cloned.groups = append(cloned.groups, name)
+	return cloned
+}
+
+// Close closes the journal socket, the next record reconnects.
+func (h *JournaldHandler) Close() error {
+	h.conn.mu.Lock()
+	defer h.conn.mu.Unlock()
+	if h.conn.conn == nil {
+		return nil
+	}
+	err := h.conn.conn.Close()
+	h.conn.conn = nil
+	return err
+}
+
+func (c *journaldConn) send(data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: c.path, Net: "unixgram"})
+		if err != nil {
+			return err
+		}
+		c.conn = conn
+	}
+
+	_, err := c.conn.Write(data)
+	if err == nil {
+		return nil
+	}
+	// the entry is too large for a datagram, send it in a memfd
+	if errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS) {
+		return sendJournaldMemfd(c.conn, data)
+	}
+	c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// JournaldFieldName returns key as a journal field name:
+// upper-case letters, digits and underscores, not starting with an underscore or a digit, at most 64 chars.
+func JournaldFieldName(key string) string {
+	b := []byte(strings.ToUpper(key))
+	for i, c := range b {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			b[i] = '_'
+		}
+	}
+	// fields starting with an underscore are trusted fields set by journald
+	b = bytes.TrimLeft(b, "_")
+	if len(b) == 0 || b[0] >= '0' && b[0] <= '9' {
+		b = append([]byte("X_"), b...)
+	}
+	if len(b) > 64 {
+		b = b[:64]
+	}
+	return string(b)
+}
+
+// appendJournaldField serializes a field, values with a newline use the binary format.
+func appendJournaldField(buf *internal.Buffer, name, value string) {
+	buf.WriteString(name)
+	if !strings.ContainsRune(value, '\n') {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+
+	buf.WriteByte('\n')
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	buf.Write(size[:])
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// PriorityPrefixHandler prefixes every line written by a handler with the <N> sd-daemon priority
+// of the record level, journald reads it from stderr of services, see sd-daemon(3).
+type PriorityPrefixHandler struct {
+	state *priorityPrefixState
+	w     io.Writer
+
+	// handler writes into state.buf
+	handler slog.Handler
+}
+
+// priorityPrefixState is shared by a PriorityPrefixHandler and the handlers derived from it.
+type priorityPrefixState struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// NewPriorityPrefixHandler returns a handler writing to w the lines of the handler made by newHandler.
+func NewPriorityPrefixHandler(w io.Writer, newHandler func(w io.Writer) slog.Handler) *PriorityPrefixHandler {
+	state := &priorityPrefixState{}
+	return &PriorityPrefixHandler{state: state, w: w, handler: newHandler(&state.buf)}
+}
+
+func (h *PriorityPrefixHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return h.handler.Enabled(ctx, l)
+}
+
+func (h *PriorityPrefixHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.state.mu.Lock()
+	defer h.state.mu.Unlock()
+
+	h.state.buf.Reset()
+	if err := h.handler.Handle(ctx, r); err != nil {
+		return err
+	}
+
+	buf := internal.NewBuffer()
+	defer buf.Free()
+	prefix := "<" + strconv.Itoa(SyslogSeverity(r.Level)) + ">"
+	for _, line := range bytes.SplitAfter(h.state.buf.Bytes(), []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		buf.WriteString(prefix)
+		buf.Write(line)
+	}
+
+	_, err := h.w.Write(buf.Bytes())
+	return err
+}
+
+func (h *PriorityPrefixHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return &PriorityPrefixHandler{state: h.state, w: h.w, handler: h.handler.WithAttrs(attrs)}
+}
+
+func (h *PriorityPrefixHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &PriorityPrefixHandler{state: h.state, w: h.w, handler: h.handler.WithGroup(name)}
+}
+
+var (
+	_ slog.Handler = (*JournaldHandler)(nil)
+	_ slog.Handler = (*PriorityPrefixHandler)(nil)
+)
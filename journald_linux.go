@@ -0,0 +1,42 @@
+package slogx
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// sendJournaldMemfd sends data in a sealed memfd, for entries too large for a datagram.
+func sendJournaldMemfd(conn *net.UnixConn, data []byte) error {
+	fd, err := unix.MemfdCreate("journal-entry", unix.MFD_CLOEXEC|unix.MFD_ALLOW_SEALING)
+	if err != nil {
+		return fmt.Errorf("memfd_create: %w", err)
+	}
+	file := os.NewFile(uintptr(fd), "journal-entry")
+	defer file.Close()
+
+	if _, err := file.Write(data); err != nil {
+		return err
+	}
+	// journald only accepts sealed memfds
+	if _, err := unix.FcntlInt(file.Fd(), unix.F_ADD_SEALS, unix.F_SEAL_SHRINK|unix.F_SEAL_GROW|unix.F_SEAL_WRITE|unix.F_SEAL_SEAL); err != nil {
+		return fmt.Errorf("seal memfd: %w", err)
+	}
+
+	// WriteMsgUnix refuses connected datagram sockets, send the fd on the raw socket
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var sendErr error
+	err = raw.Write(func(s uintptr) bool {
+		sendErr = unix.Sendmsg(int(s), nil, unix.UnixRights(int(file.Fd())), nil, 0)
+		return sendErr != unix.EAGAIN
+	})
+	if err != nil {
+		return err
+	}
+	return sendErr
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package slogx
+
+import (
+	"errors"
+	"net"
+)
+
+// sendJournaldMemfd is only supported on linux, where journald runs.
+func sendJournaldMemfd(conn *net.UnixConn, data []byte) error {
+	return errors.New("journald: entry too large, memfd is not supported on this platform")
+}
@@ -0,0 +1,243 @@
+package slogx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LevelController changes the level of a logger at runtime, it is also an http.Handler like zap AtomicLevel:
+// GET returns the levels as JSON, PUT or POST change them from a JSON body or form values,
+// with level, packages and an optional ttl after which the previous levels are restored.
+//
+//	curl -X PUT localhost:8081/log/level -d level=debug -d ttl=5m
+//	curl -X PUT localhost:8081/log/level -H 'Content-Type: application/json' -d '{"level":"debug","ttl":"5m"}'
+//
+// A LevelController is bound to the logger created with it, by NewLogger or WithLevelController,
+// it controls the level of the Output and not the ones of the sinks.
+type LevelController struct {
+	mu    sync.Mutex
+	level *slog.LevelVar
+	// packages is set when the logger has package levels, the level is their default one
+	packages *PackageLevelHandler
+	// configured is the level the logger was created with
+	configured slog.Level
+	// handler logs the level changes, it is the handler of the output without the filters
+	handler slog.Handler
+
+	// revert restores the prev levels after the ttl of the last change
+	revert    *time.Timer
+	revertsAt time.Time
+	prev      *levelSnapshot
+}
+
+type levelSnapshot struct {
+	level    slog.Level
+	packages *PackageLevels
+}
+
+// levelState is the JSON body of the levels.
+type levelState struct {
+	Level     string     `json:"level,omitempty"`
+	Packages  string     `json:"packages,omitempty"`
+	TTL       string     `json:"ttl,omitempty"`
+	RevertsAt *time.Time `json:"reverts_at,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// bind makes c control level, or packages when the logger has package levels,
+// handler is the one of the output of the logger, without its filters.
+func (c *LevelController) bind(level *slog.LevelVar, packages *PackageLevelHandler, handler slog.Handler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.level = level
+	c.packages = packages
+	c.handler = handler
+	c.configured = c.levelLocked()
+}
+
+// Level returns the level of the logger, the default one when it has package levels.
+func (c *LevelController) Level() slog.Level {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.levelLocked()
+}
+
+func (c *LevelController) levelLocked() slog.Level {
+	if c.packages != nil {
+		return c.packages.Levels().defaultLevel
+	}
+	if c.level == nil {
+		return slog.LevelInfo
+	}
+	return c.level.Level()
+}
+
+// SetLevel sets the level of the logger, the default one when it has package levels.
+func (c *LevelController) SetLevel(level slog.Level) {
+	c.SetLevelFor(level, 0)
+}
+
+// SetLevelFor sets the level of the logger and restores the previous one after ttl, when it is not 0.
+func (c *LevelController) SetLevelFor(level slog.Level, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(level, nil, ttl)
+}
+
+// PackageLevels returns the package levels, nil when the logger has none.
+func (c *LevelController) PackageLevels() *PackageLevels {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.packages == nil {
+		return nil
+	}
+	return c.packages.Levels()
+}
+
+// set changes the levels and schedules their revert, c.mu is held.
+func (c *LevelController) set(level slog.Level, packages *PackageLevels, ttl time.Duration) {
+	if c.revert != nil {
+		c.revert.Stop()
+		c.revert = nil
+		// a temporary change restores the levels before the first one
+		if ttl == 0 {
+			c.prev = nil
+		}
+	} else if ttl > 0 {
+		c.prev = &levelSnapshot{level: c.levelLocked()}
+		if c.packages != nil {
+			c.prev.packages = c.packages.Levels()
+		}
+	}
+
+	c.apply(level, packages)
+
+	if ttl > 0 {
+		c.revertsAt = time.Now().Add(ttl)
+		var timer *time.Timer
+		timer = time.AfterFunc(ttl, func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			// the levels changed again since
+			if c.revert != timer {
+				return
+			}
+			c.revert = nil
+			c.apply(c.prev.level, c.prev.packages)
+			c.prev = nil
+		})
+		c.revert = timer
+	}
+}
+
+// apply sets level, and packages when not nil, c.mu is held.
+func (c *LevelController) apply(level slog.Level, packages *PackageLevels) {
+	switch {
+	case c.packages != nil && packages != nil:
+		c.packages.SetLevels(packages.withDefault(level))
+	case c.packages != nil:
+		c.packages.SetLevels(c.packages.Levels().withDefault(level))
+	default:
+		if c.level == nil {
+			c.level = &slog.LevelVar{}
+		}
+		c.level.Set(level)
+	}
+}
+
+// state returns the levels as JSON body, c.mu is held.
+func (c *LevelController) state() levelState {
+	state := levelState{Level: strings.ToLower(LevelName(c.levelLocked()))}
+	if c.packages != nil {
+		state.Packages = c.packages.Levels().String()
+	}
+	if c.revert != nil {
+		state.RevertsAt = &c.revertsAt
+	}
+	return state
+}
+
+func (c *LevelController) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		c.mu.Lock()
+		state := c.state()
+		c.mu.Unlock()
+		writeLevelState(w, http.StatusOK, state)
+	case http.MethodPut, http.MethodPost:
+		var req levelState
+		// like zap, the body is JSON unless it is a form, the values can also be in the query
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if mediaType == "application/x-www-form-urlencoded" || r.ContentLength == 0 {
+			req = levelState{Level: r.FormValue("level"), Packages: r.FormValue("packages"), TTL: r.FormValue("ttl")}
+		} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeLevelState(w, http.StatusBadRequest, levelState{Error: "invalid JSON body: " + err.Error()})
+			return
+		}
+
+		state, err := c.update(req)
+		if err != nil {
+			writeLevelState(w, http.StatusBadRequest, levelState{Error: err.Error()})
+			return
+		}
+		writeLevelState(w, http.StatusOK, state)
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST")
+		writeLevelState(w, http.StatusMethodNotAllowed, levelState{Error: "only GET, PUT and POST are supported"})
+	}
+}
+
+// update applies the levels of a request.
+func (c *LevelController) update(req levelState) (levelState, error) {
+	var ttl time.Duration
+	if req.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(req.TTL); err != nil || ttl < 0 {
+			return levelState{}, fmt.Errorf("invalid ttl %q", req.TTL)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	level := c.levelLocked()
+	if req.Level != "" {
+		var err error
+		if level, err = parseLevelE(strings.ToLower(req.Level)); err != nil {
+			return levelState{}, err
+		}
+	}
+	var packages *PackageLevels
+	if req.Packages != "" {
+		if c.packages == nil {
+			return levelState{}, errors.New("the logger has no package levels")
+		}
+		var err error
+		if packages, err = ParsePackageLevels(req.Packages, level); err != nil {
+			return levelState{}, err
+		}
+		// a default level in packages is overridden by the level
+		if req.Level == "" {
+			level = packages.defaultLevel
+		}
+	}
+	if req.Level == "" && packages == nil {
+		return levelState{}, errors.New("level or packages is required")
+	}
+
+	c.set(level, packages, ttl)
+	return c.state(), nil
+}
+
+func writeLevelState(w http.ResponseWriter, status int, state levelState) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(state)
+}
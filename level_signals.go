@@ -0,0 +1,84 @@
+package slogx
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NotifySignals changes the level when a signal is received: cycle lowers it a step, down to debug
+// then back to the configured level, info → debug → info for example, and reset restores the configured level.
+// Each change is logged to the output, bypassing the package levels, the sampling and the dedup.
+// The returned function stops it.
+func (c *LevelController) NotifySignals(cycle, reset os.Signal) (stop func()) {
+	var sigs []os.Signal
+	for _, sig := range []os.Signal{cycle, reset} {
+		if sig != nil {
+			sigs = append(sigs, sig)
+		}
+	}
+	if len(sigs) == 0 {
+		return func() {}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sig := <-ch:
+				c.onSignal(sig, sig == cycle)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+func (c *LevelController) onSignal(sig os.Signal, cycle bool) {
+	c.mu.Lock()
+	from := c.levelLocked()
+	to := c.configured
+	if cycle {
+		to = cycleLevelDown(from, c.configured)
+	}
+	c.set(to, nil, 0)
+	handler := c.handler
+	c.mu.Unlock()
+
+	if handler == nil {
+		return
+	}
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "log level changed", 0)
+	r.AddAttrs(
+		slog.String("from", strings.ToLower(LevelName(from))),
+		slog.String("to", strings.ToLower(LevelName(to))),
+		slog.String("signal", sig.String()),
+	)
+	// without the level check of slog.Logger, the change is logged when the level is above info too
+	handler.Handle(context.Background(), r)
+}
+
+// cycleLevelDown returns the next lower of the error, warn, info and debug levels,
+// or configured after debug.
+func cycleLevelDown(l, configured slog.Level) slog.Level {
+	for _, next := range []slog.Level{slog.LevelWarn, slog.LevelInfo, slog.LevelDebug} {
+		if next < l {
+			return next
+		}
+	}
+	return configured
+}
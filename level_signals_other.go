@@ -0,0 +1,13 @@
+//go:build !windows
+
+package slogx
+
+import (
+	"os"
+	"syscall"
+)
+
+// defaultLevelSignals are the signals of WithLevelSignals.
+func defaultLevelSignals() (cycle, reset os.Signal) {
+	return syscall.SIGUSR1, syscall.SIGUSR2
+}
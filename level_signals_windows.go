@@ -0,0 +1,8 @@
+package slogx
+
+import "os"
+
+// defaultLevelSignals are the signals of WithLevelSignals, windows has no user signals.
+func defaultLevelSignals() (cycle, reset os.Signal) {
+	return nil, nil
+}
@@ -0,0 +1,208 @@
+package slogx
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// levels besides the slog ones, registered by default
+const (
+	LevelTrace  = slog.Level(-8)
+	LevelNotice = slog.Level(2)
+	LevelFatal  = slog.Level(12)
+)
+
+// fatalCloseTimeout bounds the flush of the outputs by Fatal.
+const fatalCloseTimeout = 5 * time.Second
+
+// levelRegistry holds the names of the levels, it is replaced on RegisterLevel.
+type levelRegistry struct {
+	names  map[slog.Level]string
+	levels map[string]slog.Level
+	// sorted are the named levels in increasing order
+	sorted []slog.Level
+}
+
+var (
+	levelsMu sync.Mutex
+	levels   atomic.Pointer[levelRegistry]
+)
+
+func init() {
+	levels.Store(&levelRegistry{names: map[slog.Level]string{}, levels: map[string]slog.Level{}})
+	for _, l := range []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError} {
+		RegisterLevel(l, l.String())
+	}
+	RegisterLevel(LevelTrace, "TRACE")
+	RegisterLevel(LevelNotice, "NOTICE")
+	RegisterLevel(LevelFatal, "FATAL")
+}
+
+// RegisterLevel names level, the name is case-insensitive for ParseLevel and printed uppercase by the handlers,
+// the levels between two named ones are printed as an offset like NOTICE+1.
+// It replaces the previous name of level and the previous level of name, it is meant to be called at init.
+// A name must be a word without + or -, which ParseLevel reads as an offset, and not a number.
+func RegisterLevel(level slog.Level, name string) error {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if name == "" || strings.ContainsAny(name, "+- \t\r\n") {
+		return fmt.Errorf("invalid level name %q, want a word without + or -", name)
+	}
+	if _, err := strconv.Atoi(name); err == nil {
+		return fmt.Errorf("invalid level name %q, want a word and not a number", name)
+	}
+
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	old := levels.Load()
+	r := &levelRegistry{names: map[slog.Level]string{}, levels: map[string]slog.Level{}}
+	for l, n := range old.names {
+		if l != level && n != name {
+			r.names[l] = n
+			r.levels[n] = l
+		}
+	}
+	r.names[level] = name
+	r.levels[name] = level
+	for l := range r.names {
+		r.sorted = append(r.sorted, l)
+	}
+	sort.Slice(r.sorted, func(i, j int) bool { return r.sorted[i] < r.sorted[j] })
+	levels.Store(r)
+	return nil
+}
+
+// LevelName returns the name of l, or its offset from the closest named level below it like INFO+1.
+func LevelName(l slog.Level) string {
+	r := levels.Load()
+	if name, ok := r.names[l]; ok {
+		return name
+	}
+	// the index of the first named level above l
+	i := sort.Search(len(r.sorted), func(i int) bool { return r.sorted[i] > l })
+	if i == 0 {
+		return fmt.Sprintf("%s%+d", r.names[r.sorted[0]], l-r.sorted[0])
+	}
+	return fmt.Sprintf("%s%+d", r.names[r.sorted[i-1]], l-r.sorted[i-1])
+}
+
+// ParseLevel parses a level name, case-insensitive, a number like -8, or an offset from a name like INFO+2.
+func ParseLevel(s string) (slog.Level, error) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.Atoi(s); err == nil {
+		return slog.Level(n), nil
+	}
+
+	r := levels.Load()
+	name, offset := strings.ToUpper(s), 0
+	if i := strings.IndexAny(name, "+-"); i > 0 {
+		n, err := strconv.Atoi(name[i:])
+		if err != nil {
+			return slog.LevelInfo, fmt.Errorf("invalid level offset %q", s)
+		}
+		name, offset = name[:i], n
+	}
+	level, ok := r.levels[name]
+	if !ok {
+		names := make([]string, len(r.sorted))
+		for i, l := range r.sorted {
+			names[i] = strings.ToLower(r.names[l])
+		}
+		return slog.LevelInfo, fmt.Errorf("unknown level %q, want %s, a number or an offset like info+2", s, strings.Join(names, ", "))
+	}
+	return level + slog.Level(offset), nil
+}
+
+// ReplaceLevelAttr is a slog.HandlerOptions ReplaceAttr writing the registered names of the levels,
+// slog writes DEBUG-4 for LevelTrace otherwise. It is already used by the handlers of NewHandler.
+func ReplaceLevelAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) > 0 || a.Key != slog.LevelKey {
+		return a
+	}
+	if l, ok := a.Value.Any().(slog.Level); ok {
+		if name := LevelName(l); name != l.String() {
+			a.Value = slog.StringValue(name)
+		}
+	}
+	return a
+}
+
+// Trace logs at LevelTrace.
+func (l *Logger) Trace(msg string, args ...any) {
+	logAt(context.Background(), l.Logger, LevelTrace, msg, args...)
+}
+
+// TraceContext logs at LevelTrace with ctx.
+func (l *Logger) TraceContext(ctx context.Context, msg string, args ...any) {
+	logAt(ctx, l.Logger, LevelTrace, msg, args...)
+}
+
+// Notice logs at LevelNotice.
+func (l *Logger) Notice(msg string, args ...any) {
+	logAt(context.Background(), l.Logger, LevelNotice, msg, args...)
+}
+
+// NoticeContext logs at LevelNotice with ctx.
+func (l *Logger) NoticeContext(ctx context.Context, msg string, args ...any) {
+	logAt(ctx, l.Logger, LevelNotice, msg, args...)
+}
+
+// Fatal logs at LevelFatal, closes the logger to flush its outputs and exits with status 1.
+func (l *Logger) Fatal(msg string, args ...any) {
+	logAt(context.Background(), l.Logger, LevelFatal, msg, args...)
+	l.exit()
+}
+
+// FatalContext logs at LevelFatal with ctx, closes the logger to flush its outputs and exits with status 1.
+func (l *Logger) FatalContext(ctx context.Context, msg string, args ...any) {
+	logAt(ctx, l.Logger, LevelFatal, msg, args...)
+	l.exit()
+}
+
+func (l *Logger) exit() {
+	ctx, cancel := context.WithTimeout(context.Background(), fatalCloseTimeout)
+	l.Close(ctx)
+	cancel()
+	os.Exit(1)
+}
+
+// Trace logs at LevelTrace with the default logger.
+func Trace(msg string, args ...any) {
+	logAt(context.Background(), slog.Default(), LevelTrace, msg, args...)
+}
+
+// Notice logs at LevelNotice with the default logger.
+func Notice(msg string, args ...any) {
+	logAt(context.Background(), slog.Default(), LevelNotice, msg, args...)
+}
+
+// Fatal logs at LevelFatal with the default logger, flushes the outputs with Shutdown and exits with status 1.
+func Fatal(msg string, args ...any) {
+	logAt(context.Background(), slog.Default(), LevelFatal, msg, args...)
+	ctx, cancel := context.WithTimeout(context.Background(), fatalCloseTimeout)
+	Shutdown(ctx)
+	cancel()
+	os.Exit(1)
+}
+
+// logAt logs with the source of the caller of the helper calling it, like slog.Logger.Log.
+func logAt(ctx context.Context, logger *slog.Logger, level slog.Level, msg string, args ...any) {
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	// skip [runtime.Callers, this function, the helper]
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	r.Add(args...)
+	_ = logger.Handler().Handle(ctx, r)
+}
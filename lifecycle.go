@@ -0,0 +1,204 @@
+package slogx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+)
+
+// Syncer is an output which buffers records, Sync writes them out.
+type Syncer interface {
+	Sync() error
+}
+
+// Logger is a *slog.Logger which owns the outputs it opened,
+// a log file or a syslog connection for example.
+type Logger struct {
+	*slog.Logger
+	sinks  *sinks
+	levels *LevelController
+}
+
+// NewLogger is like New, the returned Logger must be closed to release its outputs.
+func NewLogger(opts ...Option) *Logger {
+	l, _ := newLogger(newOptions(opts...), false)
+	return l
+}
+
+// newLogger creates the logger of options,
+// when strict, invalid options are errors instead of falling back to defaults.
+func newLogger(options *options, strict bool) (*Logger, error) {
+	if options.LevelController == nil {
+		options.LevelController = &LevelController{}
+	}
+
+	var h slog.Handler
+	var s *sinks
+	if strict {
+		var err error
+		if h, s, err = newHandlerE(options); err != nil {
+			return nil, err
+		}
+	} else {
+		h, s = newHandler(options)
+	}
+	if options.Tracing {
+		h = NewTracingHandler(h)
+	}
+	return &Logger{Logger: slog.New(h), sinks: s, levels: options.LevelController}, nil
+}
+
+// Levels returns the controller of the level of the logger.
+func (l *Logger) Levels() *LevelController {
+	return l.levels
+}
+
+// Sync flushes the outputs of the logger.
+func (l *Logger) Sync() error {
+	return l.sinks.sync()
+}
+
+// Close flushes and closes the outputs of the logger,
+// it returns the context error when ctx is done before they are closed.
+// Records logged after Close fail to be written.
+func (l *Logger) Close(ctx context.Context) error {
+	return runContext(ctx, l.sinks.close)
+}
+
+// Shutdown flushes and closes the outputs of every logger and handler created by slogx,
+// it returns the context error when ctx is done before they are closed.
+func Shutdown(ctx context.Context) error {
+	openedSinks.mu.Lock()
+	all := make([]*sinks, 0, len(openedSinks.sinks))
+	for s := range openedSinks.sinks {
+		all = append(all, s)
+	}
+	openedSinks.mu.Unlock()
+
+	return runContext(ctx, func() error {
+		var errs []error
+		for _, s := range all {
+			errs = append(errs, s.close())
+		}
+		return errors.Join(errs...)
+	})
+}
+
+// runContext runs fn, it returns early with the context error when ctx is done first.
+func runContext(ctx context.Context, fn func() error) error {
+	if ctx.Done() == nil {
+		return fn()
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- fn()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// closerFunc is a func() as an io.Closer.
+type closerFunc func()
+
+func (f closerFunc) Close() error {
+	f()
+	return nil
+}
+
+// asyncSink closes an AsyncHandler with the outputs, the deadline is handled by the caller.
+type asyncSink struct {
+	*AsyncHandler
+}
+
+func (s asyncSink) Close() error {
+	return s.AsyncHandler.Close(context.Background())
+}
+
+// dedupSink writes the pending summary of a DedupHandler when the outputs are closed.
+type dedupSink struct {
+	*DedupHandler
+}
+
+func (s dedupSink) Close() error {
+	return s.DedupHandler.Flush()
+}
+
+// sinks are the outputs opened for a handler, they are closed in reverse order.
+type sinks struct {
+	mu      sync.Mutex
+	closers []io.Closer
+	closed  bool
+}
+
+// openedSinks holds the sinks which are not closed yet, for Shutdown.
+var openedSinks = struct {
+	mu    sync.Mutex
+	sinks map[*sinks]struct{}
+}{sinks: map[*sinks]struct{}{}}
+
+func (s *sinks) add(c io.Closer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.closers) == 0 {
+		openedSinks.mu.Lock()
+		openedSinks.sinks[s] = struct{}{}
+		openedSinks.mu.Unlock()
+	}
+	s.closers = append(s.closers, c)
+}
+
+func (s *sinks) sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+
+	// in reverse order too, an async handler is flushed before its output
+	var errs []error
+	for i := len(s.closers) - 1; i >= 0; i-- {
+		c := s.closers[i]
+		if syncer, ok := c.(Syncer); ok {
+			errs = append(errs, syncer.Sync())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *sinks) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	openedSinks.mu.Lock()
+	delete(openedSinks.sinks, s)
+	openedSinks.mu.Unlock()
+
+	var errs []error
+	for i := len(s.closers) - 1; i >= 0; i-- {
+		c := s.closers[i]
+		if syncer, ok := c.(Syncer); ok {
+			errs = append(errs, syncer.Sync())
+		}
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
+
+var (
+	_ Syncer = (*ReopenableFile)(nil)
+	_ Syncer = (*RotatingFile)(nil)
+)
@@ -0,0 +1,390 @@
+package slogx
+
+import (
+	"context"
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ttys3/slogx/internal"
+)
+
+// DefaultLogfmtKeyOrder is the default order of the built-in keys of a logfmt line.
+var DefaultLogfmtKeyOrder = []string{slog.TimeKey, slog.LevelKey, slog.MessageKey, slog.SourceKey}
+
+// LogfmtHandler writes records as strict logfmt lines,
+// group keys are dotted and values are quoted and escaped as logfmt parsers expect.
+type LogfmtHandler struct {
+	mu *sync.Mutex
+	w  io.Writer
+
+	opts *LogfmtHandlerOptions
+
+	// preformatted holds the attrs added by WithAttrs, already formatted
+	preformatted []byte
+	groupPrefix  string
+	groups       []string
+}
+
+type LogfmtHandlerOptions struct {
+	// KeyOrder is the order of the built-in keys (time, level, msg, source),
+	// the ones not listed follow in their default order, before the attrs.
+	KeyOrder []string
+	slog.HandlerOptions
+}
+
+func NewLogfmtHandler(w io.Writer, opts *LogfmtHandlerOptions) *LogfmtHandler {
+	if opts == nil {
+		opts = &LogfmtHandlerOptions{}
+	}
+	return &LogfmtHandler{mu: &sync.Mutex{}, w: w, opts: opts}
+}
+
+func (h *LogfmtHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.opts.Level != nil {
+		minLevel = h.opts.Level.Level()
+	}
+	return l >= minLevel
+}
+
+func (h *LogfmtHandler) Handle(ctx context.Context, r slog.Record) error {
+	buf := internal.NewBuffer()
+	defer buf.Free()
+
+	for _, key := range h.keyOrder() {
+		switch key {
+		case slog.TimeKey:
+			if !r.Time.IsZero() {
+				h.appendBuiltin(buf, slog.Time(slog.TimeKey, r.Time))
+			}
+		case slog.LevelKey:
+			h.appendBuiltin(buf, slog.Any(slog.LevelKey, r.Level))
+		case slog.MessageKey:
+			h.appendBuiltin(buf, slog.String(slog.MessageKey, r.Message))
+		case slog.SourceKey:
+			if h.opts.AddSource && r.PC != 0 {
+				h.appendBuiltin(buf, slog.Any(slog.SourceKey, recordSource(r)))
+			}
+		}
+	}
+
+	buf.Write(h.preformatted)
+
+	r.Attrs(func(attr slog.Attr) bool {
+		h.appendAttr(buf, attr, h.groupPrefix, h.groups)
+		return true
+	})
+	buf.WriteByte('\n')
+
+	// every pair is preceded by a space, drop the first one
+	line := buf.Bytes()
+	if len(line) > 1 {
+		line = line[1:]
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(line)
+	return err
+}
+
+func (h *LogfmtHandler) keyOrder() []string {
+	if len(h.opts.KeyOrder) == 0 {
+		return DefaultLogfmtKeyOrder
+	}
+	order := make([]string, 0, len(DefaultLogfmtKeyOrder))
+	order = append(order, h.opts.KeyOrder...)
+	for _, key := range DefaultLogfmtKeyOrder {
+		found := false
+		for _, k := range h.opts.KeyOrder {
+			found = found || k == key
+		}
+		if !found {
+			order = append(order, key)
+		}
+	}
+	return order
+}
+
+func (h *LogfmtHandler) appendBuiltin(buf *internal.Buffer, attr slog.Attr) {
+	if h.opts.ReplaceAttr != nil {
+		attr = h.opts.ReplaceAttr(nil, attr)
+		attr.Value = attr.Value.Resolve()
+		if attr.Key == "" {
+			return
+		}
+	}
+	h.appendKeyValue(buf, attr.Key, attr.Value)
+}
+
+func (h *LogfmtHandler) appendAttr(buf *internal.Buffer, attr slog.Attr, groupPrefix string, groups []string) {
+	walkAttr(attr, groupPrefix, groups, h.opts.ReplaceAttr, func(key string, v slog.Value) {
+		h.appendKeyValue(buf, key, v)
+	})
+}
+
+// walkAttr calls fn with the dotted key and the resolved value of attr,
+// or of each attr of a group, after ReplaceAttr is applied.
+func walkAttr(attr slog.Attr, groupPrefix string, groups []string,
+	replace func(groups []string, a slog.Attr) slog.Attr, fn func(key string, v slog.Value),
+) {
+	attr.Value = attr.Value.Resolve()
+	if replace != nil && attr.Value.Kind() != slog.KindGroup {
+		attr = replace(groups, attr)
+		attr.Value = attr.Value.Resolve()
+	}
+
+	if attr.Value.Kind() == slog.KindGroup {
+		// inline the attrs of a group with an empty key
+		if attr.Key != "" {
+			groupPrefix += attr.Key + "."
+			groups = append(groups[:len(groups):len(groups)], attr.Key)
+		}
+		for _, ga := range attr.Value.Group() {
+			walkAttr(ga, groupPrefix, groups, replace, fn)
+		}
+		return
+	}
+
+	// ignore attrs with an empty key, like the stdlib handlers
+	if attr.Key == "" {
+		return
+	}
+
+	fn(groupPrefix+attr.Key, attr.Value)
+}
+
+func (h *LogfmtHandler) appendKeyValue(buf *internal.Buffer, key string, v slog.Value) {
+	buf.WriteByte(' ')
+	appendLogfmtKey(buf, key)
+	buf.WriteByte('=')
+	appendLogfmtValue(buf, logfmtValueString(v))
+}
+
+func (h *LogfmtHandler) clone() *LogfmtHandler {
+	return &LogfmtHandler{
+		mu:           h.mu,
+		w:            h.w,
+		opts:         h.opts,
+		preformatted: append([]byte(nil), h.preformatted...),
+		groupPrefix:  h.groupPrefix,
+		groups:       append([]string(nil), h.groups...),
+	}
+}
+
+func (h *LogfmtHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	cloned := h.clone()
+	buf := internal.NewBuffer()
+	defer buf.Free()
+	for _, attr := range attrs {
+		h.appendAttr(buf, attr, h.groupPrefix, h.groups)
+	}
+	cloned.preformatted = append(cloned.preformatted, buf.Bytes()...)
+	return cloned
+}
+
+func (h *LogfmtHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	cloned := h.clone()
+	cloned.groupPrefix += name + "."
+	cloned.groups = append(cloned.groups, name)
+	return cloned
+}
+
+func recordSource(r slog.Record) *slog.Source {
+	fs := runtime.CallersFrames([]uintptr{r.PC})
+	f, _ := fs.Next()
+	return &slog.Source{Function: f.Function, File: f.File, Line: f.Line}
+}
+
+func logfmtValueString(v slog.Value) string {
+	switch v.Kind() {
+	case slog.KindString:
+		return v.String()
+	case slog.KindTime:
+		return v.Time().Format(time.RFC3339Nano)
+	case slog.KindAny:
+		switch x := v.Any().(type) {
+		case error:
+			return x.Error()
+		case encoding.TextMarshaler:
+			data, err := x.MarshalText()
+			if err != nil {
+				return "!ERROR:" + err.Error()
+			}
+			return string(data)
+		case []byte:
+			return string(x)
+		case *slog.Source:
+			return fmt.Sprintf("%s:%d", x.File, x.Line)
+		}
+	}
+	return v.String()
+}
+
+// appendLogfmtKey writes key, replacing the characters not allowed in a logfmt key with '_'.
+func appendLogfmtKey(buf *internal.Buffer, key string) {
+	for _, r := range key {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || !unicode.IsPrint(r) {
+			r = '_'
+		}
+		buf.WriteString(string(r))
+	}
+}
+
+// appendLogfmtValue writes s, quoted and escaped when needed.
+func appendLogfmtValue(buf *internal.Buffer, s string) {
+	if !logfmtNeedsQuote(s) {
+		buf.WriteString(s)
+		return
+	}
+
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(byte(r))
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < ' ' || r == 0x7f {
+				buf.WriteString(fmt.Sprintf(`\u%04x`, r))
+				continue
+			}
+			buf.WriteString(string(r))
+		}
+	}
+	buf.WriteByte('"')
+}
+
+func logfmtNeedsQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == 0x7f || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// LogfmtField is a key value pair of a logfmt line.
+type LogfmtField struct {
+	Key   string
+	Value string
+}
+
+// ParseLogfmt parses a logfmt line into its fields,
+// a key without '=' has an empty value.
+func ParseLogfmt(line string) ([]LogfmtField, error) {
+	var fields []LogfmtField
+	i := 0
+	for {
+		for i < len(line) && (line[i] == ' ' || line[i] == '\t' || line[i] == '\n') {
+			i++
+		}
+		if i >= len(line) {
+			return fields, nil
+		}
+
+		start := i
+		for i < len(line) && line[i] > ' ' && line[i] != '=' && line[i] != '"' {
+			i++
+		}
+		if i == start {
+			return nil, fmt.Errorf("logfmt: unexpected %q at offset %d", line[i], i)
+		}
+		field := LogfmtField{Key: line[start:i]}
+
+		if i < len(line) && line[i] == '=' {
+			i++
+			if i < len(line) && line[i] == '"' {
+				value, n, err := unquoteLogfmt(line[i:])
+				if err != nil {
+					return nil, fmt.Errorf("logfmt: value of %q at offset %d: %w", field.Key, i, err)
+				}
+				field.Value = value
+				i += n
+			} else {
+				start := i
+				for i < len(line) && line[i] > ' ' && line[i] != '=' && line[i] != '"' {
+					i++
+				}
+				field.Value = line[start:i]
+			}
+		}
+		if i < len(line) && line[i] > ' ' {
+			return nil, fmt.Errorf("logfmt: unexpected %q at offset %d", line[i], i)
+		}
+		fields = append(fields, field)
+	}
+}
+
+var errUnterminatedQuote = errors.New("unterminated quoted value")
+
+// unquoteLogfmt unquotes the quoted value at the start of s,
+// it returns the value and the number of bytes consumed.
+func unquoteLogfmt(s string) (string, int, error) {
+	buf := internal.NewBuffer()
+	defer buf.Free()
+
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			return buf.String(), i + 1, nil
+		case '\\':
+			i++
+			if i >= len(s) {
+				return "", 0, errUnterminatedQuote
+			}
+			switch s[i] {
+			case '"', '\\', '/':
+				buf.WriteByte(s[i])
+			case 'n':
+				buf.WriteByte('\n')
+			case 'r':
+				buf.WriteByte('\r')
+			case 't':
+				buf.WriteByte('\t')
+			case 'u':
+				if i+4 >= len(s) {
+					return "", 0, errUnterminatedQuote
+				}
+				r, err := strconv.ParseUint(s[i+1:i+5], 16, 32)
+				if err != nil {
+					return "", 0, fmt.Errorf("invalid escape \\u%s", s[i+1:i+5])
+				}
+				buf.WriteString(string(rune(r)))
+				i += 4
+			default:
+				return "", 0, fmt.Errorf("invalid escape \\%c", s[i])
+			}
+		default:
+			buf.WriteByte(s[i])
+		}
+	}
+	return "", 0, errUnterminatedQuote
+}
+
+var _ slog.Handler = (*LogfmtHandler)(nil)
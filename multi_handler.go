@@ -0,0 +1,70 @@
+package slogx
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// MultiHandler fans records out to several handlers, each one with its own level.
+type MultiHandler struct {
+	handlers []slog.Handler
+}
+
+// Multi returns a handler sending records to every handler enabled for their level.
+func Multi(handlers ...slog.Handler) *MultiHandler {
+	return &MultiHandler{handlers: handlers}
+}
+
+// Handlers returns the handlers records are sent to.
+func (h *MultiHandler) Handlers() []slog.Handler {
+	return h.handlers
+}
+
+func (h *MultiHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, l) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle sends r to every enabled handler, a failing handler does not stop the others.
+func (h *MultiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, handler := range h.handlers {
+		if !handler.Enabled(ctx, r.Level) {
+			continue
+		}
+		// a handler may retain the record, the attrs must not be shared
+		if err := handler.Handle(ctx, r.Clone()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h *MultiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+	return &MultiHandler{handlers: handlers}
+}
+
+func (h *MultiHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithGroup(name)
+	}
+	return &MultiHandler{handlers: handlers}
+}
+
+var _ slog.Handler = (*MultiHandler)(nil)
@@ -1,7 +1,14 @@
 package slogx
 
 import (
+	"errors"
+	"fmt"
 	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"syscall"
 )
 
 // Option is an application option.
@@ -11,18 +18,145 @@ type Options struct {
 	DisableSource bool
 	FullSource    bool
 	DisableTime   bool
-	DisableColor  bool // for cli
+	DisableColor  bool         // for cli and pretty-json
+	TagKey        string       // for cli, attr key rendered as [tag] prefix
+	TagFromGroup  bool         // for cli, outermost group name rendered as [tag] prefix
+	TagWidth      int          // for cli, width of the [tag] prefix, default to 8
+	CollapseAttrs CollapseMode // for cli, collapse repeated With attrs
+
+	PriorityPrefix bool // prefix lines with <N> sd-daemon priority, for services under systemd
 }
 
 // options is an application options.
 type options struct {
 	Options
 
-	Level   string    // debug, info, warn, error
-	Format  string    // json, text
-	Output  string    // stdout, stderr, discard, or a file path
+	Level   string    // trace, debug, info, notice, warn, error, fatal, see ParseLevel
+	Format  string    // json, text, logfmt, ecs, gcp, cli, pretty-json
+	Output  string    // stdout, stderr, discard, syslog+udp://host:514 like syslog url, journald, or a file path
 	Writer  io.Writer // set this to override Output
 	Tracing bool      // enable tracing feature
+
+	PackageLevels   string           // override Level by package, like info,github.com/acme/db=debug
+	LevelController *LevelController // change the levels at runtime
+	LevelSignals    bool             // change the level on SIGUSR1 and SIGUSR2, see LevelController.NotifySignals
+
+	envPrefix string // read the environment variables with this prefix, see WithEnv
+	envErr    error  // the invalid environment variables
+
+	Rotation      *Rotation   // rotate the Output file
+	ReopenSignals []os.Signal // reopen the Output file on these signals, for external logrotate
+
+	Sinks    []sinkSpec              // more outputs besides Output, see WithSink
+	Async    *AsyncHandlerOptions    // write the records in the background, see AsyncHandler
+	Sampling *SamplingHandlerOptions // sample the repeated records, see SamplingHandler
+	Dedup    *DedupHandlerOptions    // summarize the repeats of a record, see DedupHandler
+	Redact   *RedactHandlerOptions   // redact the secrets and PII, see RedactHandler
+}
+
+// sinkSpec is an output added by WithSink.
+type sinkSpec struct {
+	format string
+	output string
+	level  string
+	opts   []Option
+}
+
+// options returns the options of the sink, they inherit the Options of parent.
+func (c sinkSpec) options(parent *options) *options {
+	o := &options{Options: parent.Options, Level: "info", Format: "json", Output: "stderr"}
+	WithFormat(c.format)(o)
+	WithOutput(c.output)(o)
+	WithLevel(c.level)(o)
+	for _, opt := range c.opts {
+		opt(o)
+	}
+	return o
+}
+
+// formats are the supported Format values.
+var formats = []string{"json", "text", "logfmt", "ecs", "gcp", "cli", "pretty-json"}
+
+// validate returns the errors of invalid or conflicting options.
+func (o *options) validate() error {
+	var errs []error
+	if o.envErr != nil {
+		errs = append(errs, o.envErr)
+	}
+	if _, err := parseLevelE(o.Level); err != nil {
+		errs = append(errs, err)
+	}
+	if o.Format != "" && !slices.Contains(formats, o.Format) {
+		errs = append(errs, fmt.Errorf("unknown format %q, want one of %s", o.Format, strings.Join(formats, ", ")))
+	}
+	if o.PackageLevels != "" {
+		if _, err := ParsePackageLevels(o.PackageLevels, slog.LevelInfo); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if o.FullSource && o.DisableSource {
+		errs = append(errs, errors.New("FullSource and DisableSource are exclusive"))
+	}
+	if !isFileOutput(o) {
+		if o.Rotation != nil {
+			errs = append(errs, fmt.Errorf("rotation needs a file output, got %q", o.Output))
+		}
+		if o.ReopenSignals != nil {
+			errs = append(errs, fmt.Errorf("reopen signals need a file output, got %q", o.Output))
+		}
+	}
+	if o.PriorityPrefix && o.Writer == nil && (isSyslogOutput(o.Output) || isJournaldOutput(o.Output)) {
+		errs = append(errs, fmt.Errorf("priority prefix is not supported by the %q output", o.Output))
+	}
+	return errors.Join(errs...)
+}
+
+// WithSink writes the records to one more output, with its own format and level,
+// opts set the other options of the sink, which otherwise inherits the Options of the logger.
+// Records go to the Output and to every sink enabled for their level.
+func WithSink(format, output, level string, opts ...Option) Option {
+	return func(o *options) {
+		o.Sinks = append(o.Sinks, sinkSpec{format: format, output: output, level: level, opts: opts})
+	}
+}
+
+// WithAsync writes the records in the background, in batches, see AsyncHandler.
+// The logger must be closed, or Shutdown called, for the queued records to be written.
+func WithAsync(opts AsyncHandlerOptions) Option {
+	return func(o *options) { o.Async = &opts }
+}
+
+// WithSampling drops the repeated records, see SamplingHandler.
+func WithSampling(opts SamplingHandlerOptions) Option {
+	return func(o *options) { o.Sampling = &opts }
+}
+
+// WithDedup summarizes the repeats of a record, see DedupHandler.
+// The logger must be closed, or Shutdown called, for the last summary to be written.
+func WithDedup(opts DedupHandlerOptions) Option {
+	return func(o *options) { o.Dedup = &opts }
+}
+
+// WithRedact redacts the sensitive attrs and values of the records, see RedactHandler.
+func WithRedact(opts RedactHandlerOptions) Option {
+	return func(o *options) { o.Redact = &opts }
+}
+
+// WithPackageLevels sets the levels by package, like info,github.com/acme/db=debug,github.com/acme/http=warn,
+// the package of a record is the one of the function it is logged from.
+func WithPackageLevels(levels string) Option {
+	return func(o *options) { o.PackageLevels = levels }
+}
+
+// WithLevelController binds c to the logger, to change its level at runtime.
+func WithLevelController(c *LevelController) Option {
+	return func(o *options) { o.LevelController = c }
+}
+
+// WithLevelSignals lowers the level on SIGUSR1, down to debug then back to Level, and restores Level on SIGUSR2.
+// The signals are not supported on windows.
+func WithLevelSignals() Option {
+	return func(o *options) { o.LevelSignals = true }
 }
 
 func WithDisableSource() Option {
@@ -37,6 +171,10 @@ func WithDisableTime() Option {
 	return func(o *options) { o.DisableTime = true }
 }
 
+func WithDisableColor() Option {
+	return func(o *options) { o.DisableColor = true }
+}
+
 func WithLevel(level string) Option {
 	return func(o *options) {
 		if level == "" {
@@ -71,3 +209,39 @@ func WithWriter(w io.Writer) Option {
 func WithTracing() Option {
 	return func(o *options) { o.Tracing = true }
 }
+
+func WithTagKey(key string) Option {
+	return func(o *options) { o.TagKey = key }
+}
+
+func WithTagFromGroup() Option {
+	return func(o *options) { o.TagFromGroup = true }
+}
+
+func WithTagWidth(width int) Option {
+	return func(o *options) { o.TagWidth = width }
+}
+
+// WithCollapseAttrs collapses the repeated With attrs of the cli format, with a mark or hidden.
+func WithCollapseAttrs(mode CollapseMode) Option {
+	return func(o *options) { o.CollapseAttrs = mode }
+}
+
+func WithPriorityPrefix() Option {
+	return func(o *options) { o.PriorityPrefix = true }
+}
+
+// WithRotation rotates the Output file, see Rotation.
+func WithRotation(rotation Rotation) Option {
+	return func(o *options) { o.Rotation = &rotation }
+}
+
+// WithReopenSignal reopens the Output file when one of sigs is received, SIGHUP by default.
+func WithReopenSignal(sigs ...os.Signal) Option {
+	return func(o *options) {
+		if len(sigs) == 0 {
+			sigs = []os.Signal{syscall.SIGHUP}
+		}
+		o.ReopenSignals = sigs
+	}
+}
@@ -0,0 +1,187 @@
+package slogx
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// PackageLevels are log levels by package, like info,github.com/acme/db=debug,github.com/acme/http=warn:
+// the level without a package is the default one, a package rule applies to the package and its sub packages,
+// the most specific rule wins.
+type PackageLevels struct {
+	defaultLevel slog.Level
+	// rules are sorted by decreasing prefix length, the first match is the most specific one
+	rules []packageRule
+
+	// cache holds the level of the packages already looked up
+	cache sync.Map
+}
+
+type packageRule struct {
+	prefix string
+	level  slog.Level
+}
+
+// ParsePackageLevels parses comma separated levels, defaultLevel is used when none is without a package.
+func ParsePackageLevels(s string, defaultLevel slog.Level) (*PackageLevels, error) {
+	levels := &PackageLevels{defaultLevel: defaultLevel}
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		pkg, name, ok := strings.Cut(item, "=")
+		if !ok {
+			name, pkg = pkg, ""
+		}
+		level, err := parseLevelE(strings.ToLower(strings.TrimSpace(name)))
+		if err != nil {
+			return nil, fmt.Errorf("package levels %q: %w", item, err)
+		}
+		pkg = strings.TrimSuffix(strings.TrimSpace(pkg), "/")
+		if pkg == "" {
+			levels.defaultLevel = level
+			continue
+		}
+		levels.rules = append(levels.rules, packageRule{prefix: pkg, level: level})
+	}
+	sort.SliceStable(levels.rules, func(i, j int) bool {
+		return len(levels.rules[i].prefix) > len(levels.rules[j].prefix)
+	})
+	return levels, nil
+}
+
+// Level returns the level of the package pkg.
+func (l *PackageLevels) Level(pkg string) slog.Level {
+	if level, ok := l.cache.Load(pkg); ok {
+		return level.(slog.Level)
+	}
+	level := l.defaultLevel
+	for _, rule := range l.rules {
+		if pkg == rule.prefix || strings.HasPrefix(pkg, rule.prefix) && pkg[len(rule.prefix)] == '/' {
+			level = rule.level
+			break
+		}
+	}
+	l.cache.Store(pkg, level)
+	return level
+}
+
+// withDefault returns a copy of the levels with another default level.
+func (l *PackageLevels) withDefault(level slog.Level) *PackageLevels {
+	return &PackageLevels{defaultLevel: level, rules: l.rules}
+}
+
+// Min returns the lowest of the levels.
+func (l *PackageLevels) Min() slog.Level {
+	level := l.defaultLevel
+	for _, rule := range l.rules {
+		level = min(level, rule.level)
+	}
+	return level
+}
+
+func (l *PackageLevels) String() string {
+	items := []string{strings.ToLower(LevelName(l.defaultLevel))}
+	for i := len(l.rules) - 1; i >= 0; i-- {
+		items = append(items, l.rules[i].prefix+"="+strings.ToLower(LevelName(l.rules[i].level)))
+	}
+	return strings.Join(items, ",")
+}
+
+// PackageLevelHandler filters the records by the level of the package they are logged from,
+// the package is found from the function of the record PC.
+// The handler must be enabled for the lowest of the levels, see PackageLevels.Min.
+type PackageLevelHandler struct {
+	state   *packageLevelState
+	handler slog.Handler
+}
+
+// packageLevelState is shared by a PackageLevelHandler and the handlers derived from it.
+type packageLevelState struct {
+	levels atomic.Pointer[PackageLevels]
+	// packages holds the package of the PCs already looked up
+	packages sync.Map
+}
+
+func NewPackageLevelHandler(handler slog.Handler, levels *PackageLevels) *PackageLevelHandler {
+	state := &packageLevelState{}
+	state.levels.Store(levels)
+	return &PackageLevelHandler{state: state, handler: handler}
+}
+
+// Levels returns the current levels.
+func (h *PackageLevelHandler) Levels() *PackageLevels {
+	return h.state.levels.Load()
+}
+
+// SetLevels replaces the levels, of the handlers derived from h too.
+func (h *PackageLevelHandler) SetLevels(levels *PackageLevels) {
+	h.state.levels.Store(levels)
+}
+
+func (h *PackageLevelHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return l >= h.Levels().Min() && h.handler.Enabled(ctx, l)
+}
+
+func (h *PackageLevelHandler) Handle(ctx context.Context, r slog.Record) error {
+	levels := h.Levels()
+	level := levels.defaultLevel
+	if r.PC != 0 {
+		level = levels.Level(h.state.pkg(r.PC))
+	}
+	if r.Level < level {
+		return nil
+	}
+	return h.handler.Handle(ctx, r)
+}
+
+// pkg returns the import path of the package of the function at pc.
+func (s *packageLevelState) pkg(pc uintptr) string {
+	if pkg, ok := s.packages.Load(pc); ok {
+		return pkg.(string)
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	pkg := packageOfFunc(frame.Function)
+	s.packages.Store(pc, pkg)
+	return pkg
+}
+
+// packageOfFunc returns the package of a function name like github.com/acme/db.(*Conn).Query.func1.
+// The dots of the last path element are escaped, like gopkg.in/yaml%2ev3.Marshal, so are '%' and a few other bytes.
+func packageOfFunc(fn string) string {
+	slash := strings.LastIndexByte(fn, '/')
+	pkg := fn
+	if dot := strings.IndexByte(fn[slash+1:], '.'); dot >= 0 {
+		pkg = fn[:slash+1+dot]
+	}
+	if strings.Contains(pkg, "%") {
+		if unescaped, err := url.PathUnescape(pkg); err == nil {
+			pkg = unescaped
+		}
+	}
+	return pkg
+}
+
+func (h *PackageLevelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return &PackageLevelHandler{state: h.state, handler: h.handler.WithAttrs(attrs)}
+}
+
+func (h *PackageLevelHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &PackageLevelHandler{state: h.state, handler: h.handler.WithGroup(name)}
+}
+
+var _ slog.Handler = (*PackageLevelHandler)(nil)
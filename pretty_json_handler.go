@@ -0,0 +1,189 @@
+package slogx
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+	"github.com/ttys3/slogx/internal"
+)
+
+// json syntax colors, the level value uses the Colors mapping.
+var (
+	jsonKeyColor     = color.New(color.FgCyan)
+	jsonStringColor  = color.New(color.FgGreen)
+	jsonNumberColor  = color.New(color.FgMagenta)
+	jsonLiteralColor = color.New(color.FgYellow)
+)
+
+// PrettyJSONHandler writes records as indented, colorized json, for local development.
+// The json is produced by slog.JSONHandler, so it has the same shape as the json format.
+type PrettyJSONHandler struct {
+	state *prettyJSONState
+	w     io.Writer
+	opts  *PrettyJSONHandlerOptions
+
+	// handler writes the compact json into state.buf
+	handler slog.Handler
+}
+
+type PrettyJSONHandlerOptions struct {
+	DisableColor bool
+	// Indent is the indentation of nested values, default to two spaces.
+	Indent string
+	slog.HandlerOptions
+}
+
+// prettyJSONState is shared by a PrettyJSONHandler and the handlers derived from it.
+type prettyJSONState struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func NewPrettyJSONHandler(w io.Writer, opts *PrettyJSONHandlerOptions) *PrettyJSONHandler {
+	if opts == nil {
+		opts = &PrettyJSONHandlerOptions{}
+	}
+	state := &prettyJSONState{}
+	return &PrettyJSONHandler{
+		state:   state,
+		w:       w,
+		opts:    opts,
+		handler: slog.NewJSONHandler(&state.buf, &opts.HandlerOptions),
+	}
+}
+
+func (h *PrettyJSONHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return h.handler.Enabled(ctx, l)
+}
+
+func (h *PrettyJSONHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.state.mu.Lock()
+	defer h.state.mu.Unlock()
+
+	h.state.buf.Reset()
+	if err := h.handler.Handle(ctx, r); err != nil {
+		return err
+	}
+
+	buf := internal.NewBuffer()
+	defer buf.Free()
+
+	h.setColors(r.Level)
+	indent := h.opts.Indent
+	if indent == "" {
+		indent = "  "
+	}
+	prettyJSON(buf, bytes.TrimSpace(h.state.buf.Bytes()), indent, r.Level)
+	buf.WriteByte('\n')
+
+	_, err := h.w.Write(buf.Bytes())
+	return err
+}
+
+func (h *PrettyJSONHandler) setColors(level slog.Level) {
+	for _, c := range []*color.Color{jsonKeyColor, jsonStringColor, jsonNumberColor, jsonLiteralColor, levelColor(level)} {
+		if h.opts.DisableColor {
+			c.DisableColor()
+		} else {
+			c.EnableColor()
+		}
+	}
+}
+
+func (h *PrettyJSONHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return &PrettyJSONHandler{state: h.state, w: h.w, opts: h.opts, handler: h.handler.WithAttrs(attrs)}
+}
+
+func (h *PrettyJSONHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &PrettyJSONHandler{state: h.state, w: h.w, opts: h.opts, handler: h.handler.WithGroup(name)}
+}
+
+// levelColor returns the color of the highest mapped level not above l.
+func levelColor(l slog.Level) *color.Color {
+	return levelMapping(Colors, l)
+}
+
+// prettyJSON indents and colorizes the compact json object src into buf.
+func prettyJSON(buf *internal.Buffer, src []byte, indent string, level slog.Level) {
+	depth := 0
+	newline := func() {
+		buf.WriteByte('\n')
+		buf.WriteString(strings.Repeat(indent, depth))
+	}
+
+	isLevel := false
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch c {
+		case '{', '[':
+			buf.WriteByte(c)
+			// keep empty objects and arrays on one line
+			if i+1 < len(src) && (src[i+1] == '}' || src[i+1] == ']') {
+				buf.WriteByte(src[i+1])
+				i++
+				continue
+			}
+			depth++
+			newline()
+		case '}', ']':
+			depth--
+			newline()
+			buf.WriteByte(c)
+		case ',':
+			buf.WriteByte(c)
+			newline()
+		case ':':
+			buf.WriteString(": ")
+		case '"':
+			end := i + 1
+			for ; end < len(src) && src[end] != '"'; end++ {
+				if src[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(src) {
+				end = len(src) - 1
+			}
+			str := string(src[i : end+1])
+			i = end
+
+			switch {
+			case i+1 < len(src) && src[i+1] == ':':
+				isLevel = depth == 1 && str == `"`+slog.LevelKey+`"`
+				buf.WriteString(jsonKeyColor.Sprint(str))
+			case isLevel:
+				isLevel = false
+				buf.WriteString(levelColor(level).Sprint(str))
+			default:
+				buf.WriteString(jsonStringColor.Sprint(str))
+			}
+		default:
+			// number, true, false or null
+			end := i
+			for end < len(src) && !strings.ContainsRune(",:{}[]\" ", rune(src[end])) {
+				end++
+			}
+			literal := string(src[i:end])
+			i = end - 1
+			isLevel = false
+			if literal == "true" || literal == "false" || literal == "null" {
+				buf.WriteString(jsonLiteralColor.Sprint(literal))
+			} else {
+				buf.WriteString(jsonNumberColor.Sprint(literal))
+			}
+		}
+	}
+}
+
+var _ slog.Handler = (*PrettyJSONHandler)(nil)
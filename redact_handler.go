@@ -0,0 +1,199 @@
+package slogx
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// RedactedMask replaces the sensitive values by default.
+const RedactedMask = "***"
+
+// patterns of sensitive values
+var (
+	RedactEmail       = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	RedactCardNumber  = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+	RedactBearerToken = regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`)
+	RedactAWSKey      = regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)
+	RedactJWT         = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+)
+
+// DefaultRedactPatterns are the patterns of emails, card numbers, bearer tokens, AWS access keys and JWTs.
+var DefaultRedactPatterns = []*regexp.Regexp{RedactEmail, RedactCardNumber, RedactBearerToken, RedactAWSKey, RedactJWT}
+
+type RedactHandlerOptions struct {
+	// Keys are the keys of the attrs whose value is redacted, matched case-insensitively as path.Match globs
+	// against the key and against the dotted path of the key in its groups,
+	// "password", "*token*" and "req.headers.authorization" for example.
+	Keys []string
+	// Patterns are the patterns of the sensitive parts of the message and of the string values.
+	Patterns []*regexp.Regexp
+	// Replace returns the replacement of a sensitive value, default to RedactMask.
+	Replace func(s string) string
+}
+
+// RedactMask replaces s with RedactedMask.
+func RedactMask(s string) string {
+	return RedactedMask
+}
+
+// RedactPartial returns a replacement keeping the last keep chars of a value,
+// values too short to hide anything are fully masked.
+func RedactPartial(keep int) func(s string) string {
+	return func(s string) string {
+		n := utf8.RuneCountInString(s)
+		if n <= keep*2 {
+			return RedactedMask
+		}
+		runes := []rune(s)
+		return RedactedMask + string(runes[n-keep:])
+	}
+}
+
+// RedactHash returns a replacement by the salted sha256 of a value,
+// the same values can be correlated without being revealed.
+func RedactHash(salt string) func(s string) string {
+	return func(s string) string {
+		sum := sha256.Sum256([]byte(salt + s))
+		return "sha256:" + hex.EncodeToString(sum[:8])
+	}
+}
+
+// RedactHandler redacts the sensitive attrs and values of the records before the handler formats them,
+// the ReplaceAttr of the handler still applies to the redacted attrs.
+// Values are redacted through groups, LogValuers and maps with string keys.
+type RedactHandler struct {
+	opts    *RedactHandlerOptions
+	handler slog.Handler
+	groups  []string
+}
+
+func NewRedactHandler(handler slog.Handler, opts *RedactHandlerOptions) *RedactHandler {
+	o := RedactHandlerOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	keys := make([]string, len(o.Keys))
+	for i, key := range o.Keys {
+		keys[i] = strings.ToLower(key)
+	}
+	o.Keys = keys
+	if o.Replace == nil {
+		o.Replace = RedactMask
+	}
+	return &RedactHandler{opts: &o, handler: handler}
+}
+
+func (h *RedactHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return h.handler.Enabled(ctx, l)
+}
+
+func (h *RedactHandler) Handle(ctx context.Context, r slog.Record) error {
+	redacted := slog.NewRecord(r.Time, r.Level, h.redactString(r.Message), r.PC)
+	r.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(h.redactAttr(h.groups, attr))
+		return true
+	})
+	return h.handler.Handle(ctx, redacted)
+}
+
+func (h *RedactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	redacted := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redacted[i] = h.redactAttr(h.groups, attr)
+	}
+	return &RedactHandler{opts: h.opts, handler: h.handler.WithAttrs(redacted), groups: h.groups}
+}
+
+func (h *RedactHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	groups := append(append([]string(nil), h.groups...), name)
+	return &RedactHandler{opts: h.opts, handler: h.handler.WithGroup(name), groups: groups}
+}
+
+func (h *RedactHandler) redactAttr(groups []string, attr slog.Attr) slog.Attr {
+	attr.Value = attr.Value.Resolve()
+	if h.sensitiveKey(groups, attr.Key) {
+		attr.Value = slog.StringValue(h.opts.Replace(attr.Value.String()))
+		return attr
+	}
+	attr.Value = h.redactValue(groups, attr.Key, attr.Value)
+	return attr
+}
+
+func (h *RedactHandler) redactValue(groups []string, key string, v slog.Value) slog.Value {
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.StringValue(h.redactString(v.String()))
+	case slog.KindGroup:
+		// the attrs of an inline group, with an empty key, stay in the groups
+		if key != "" {
+			groups = append(groups[:len(groups):len(groups)], key)
+		}
+		attrs := v.Group()
+		redacted := make([]slog.Attr, len(attrs))
+		for i, attr := range attrs {
+			redacted[i] = h.redactAttr(groups, attr)
+		}
+		return slog.GroupValue(redacted...)
+	case slog.KindAny:
+		rv := reflect.ValueOf(v.Any())
+		if rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String {
+			return slog.AnyValue(h.redactMap(append(groups[:len(groups):len(groups)], key), rv))
+		}
+	}
+	return v
+}
+
+// redactMap returns a copy of the map m with its values redacted.
+func (h *RedactHandler) redactMap(groups []string, m reflect.Value) map[string]any {
+	redacted := make(map[string]any, m.Len())
+	iter := m.MapRange()
+	for iter.Next() {
+		key := iter.Key().String()
+		attr := h.redactAttr(groups, slog.Any(key, iter.Value().Interface()))
+		redacted[key] = attr.Value.Any()
+	}
+	return redacted
+}
+
+func (h *RedactHandler) redactString(s string) string {
+	for _, re := range h.opts.Patterns {
+		s = re.ReplaceAllStringFunc(s, h.opts.Replace)
+	}
+	return s
+}
+
+// sensitiveKey reports whether key, or its dotted path in groups, matches one of the Keys.
+func (h *RedactHandler) sensitiveKey(groups []string, key string) bool {
+	if key == "" || len(h.opts.Keys) == 0 {
+		return false
+	}
+	key = strings.ToLower(key)
+	full := key
+	if len(groups) > 0 {
+		full = strings.ToLower(strings.Join(groups, ".")) + "." + key
+	}
+	for _, pattern := range h.opts.Keys {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, full); ok {
+			return true
+		}
+	}
+	return false
+}
+
+var _ slog.Handler = (*RedactHandler)(nil)
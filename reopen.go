@@ -0,0 +1,181 @@
+package slogx
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// Reopener is a log file which can be reopened at its path,
+// after it was moved away by an external logrotate for example.
+type Reopener interface {
+	Reopen() error
+}
+
+// openedFiles holds the files opened by NewHandler, for ReopenFiles.
+var openedFiles = struct {
+	mu    sync.Mutex
+	files map[Reopener]struct{}
+}{files: map[Reopener]struct{}{}}
+
+func registerFile(f Reopener) {
+	openedFiles.mu.Lock()
+	defer openedFiles.mu.Unlock()
+	openedFiles.files[f] = struct{}{}
+}
+
+func unregisterFile(f Reopener) {
+	openedFiles.mu.Lock()
+	defer openedFiles.mu.Unlock()
+	delete(openedFiles.files, f)
+}
+
+// ReopenFiles reopens every file output opened by NewHandler.
+func ReopenFiles() error {
+	openedFiles.mu.Lock()
+	files := make([]Reopener, 0, len(openedFiles.files))
+	for f := range openedFiles.files {
+		files = append(files, f)
+	}
+	openedFiles.mu.Unlock()
+
+	var errs []error
+	for _, f := range files {
+		errs = append(errs, f.Reopen())
+	}
+	return errors.Join(errs...)
+}
+
+// NotifyReopen reopens f when one of sigs is received, SIGHUP by default.
+// The returned function stops it.
+func NotifyReopen(f Reopener, sigs ...os.Signal) (stop func()) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ch:
+				if err := f.Reopen(); err != nil {
+					// there is no logger to report to, the file stays the previous one
+					os.Stderr.WriteString("slogx: failed to reopen log file: " + err.Error() + "\n")
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+// ReopenableFile is a log file which can be reopened at its path,
+// records written concurrently go either to the previous or to the new file, never to both.
+type ReopenableFile struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// OpenReopenableFile opens or creates the file at path, appending to it.
+func OpenReopenableFile(path string) (*ReopenableFile, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &ReopenableFile{path: path, file: file}, nil
+}
+
+func (f *ReopenableFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	return f.file.Write(p)
+}
+
+// Reopen opens the file at its path again and swaps it with the current one.
+func (f *ReopenableFile) Reopen() error {
+	// open first so writes are not blocked by the open
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	old := f.file
+	if old == nil {
+		f.mu.Unlock()
+		file.Close()
+		return os.ErrClosed
+	}
+	f.file = file
+	f.mu.Unlock()
+
+	return old.Close()
+}
+
+// Sync commits the written records to disk.
+func (f *ReopenableFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return os.ErrClosed
+	}
+	return f.file.Sync()
+}
+
+func (f *ReopenableFile) Close() error {
+	unregisterFile(f)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// Reopen opens the file at its path again, without rotating it,
+// the current file is kept when the open fails.
+func (f *RotatingFile) Reopen() error {
+	// open first so writes are not blocked by the open
+	file, info, err := openLogFile(f.path)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	old := f.file
+	if old == nil {
+		f.mu.Unlock()
+		file.Close()
+		return os.ErrClosed
+	}
+	f.setFile(file, info)
+	f.mu.Unlock()
+
+	return old.Close()
+}
+
+var (
+	_ io.WriteCloser = (*ReopenableFile)(nil)
+	_ Reopener       = (*ReopenableFile)(nil)
+	_ Reopener       = (*RotatingFile)(nil)
+)
@@ -0,0 +1,406 @@
+package slogx
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RotateEvery is the period of time based rotation.
+type RotateEvery string
+
+const (
+	RotateNever  RotateEvery = ""
+	RotateHourly RotateEvery = "hourly"
+	RotateDaily  RotateEvery = "daily"
+)
+
+// backup timestamp formats, the backup of app.log is app-<timestamp>.log
+const (
+	hourlyBackupFormat = "2006-01-02T15"
+	dailyBackupFormat  = "2006-01-02"
+	sizeBackupFormat   = "2006-01-02T15-04-05.000"
+)
+
+// Rotation configures the rotation of a log file.
+type Rotation struct {
+	// MaxSize rotates the file before it grows over MaxSize bytes, no size rotation when 0.
+	MaxSize int64
+	// Every rotates the file at the start of every hour or day, in local time.
+	Every RotateEvery
+	// MaxBackups is the number of rotated files to keep, all are kept when 0.
+	MaxBackups int
+	// MaxAge removes the rotated files older than it, none are removed when 0.
+	MaxAge time.Duration
+	// Compress gzips the rotated files.
+	Compress bool
+}
+
+// RotatingFile is a log file rotated by size and/or time,
+// the current file is renamed to a timestamped backup and a new file is created in its place.
+type RotatingFile struct {
+	mu       sync.Mutex
+	path     string
+	rotation Rotation
+
+	file *os.File
+	size int64
+	// period is the start of the time period of the current file
+	period time.Time
+
+	// mill compresses and removes the backups in the background
+	millCh chan struct{}
+	millWg sync.WaitGroup
+}
+
+// NewRotatingFile opens or creates the file at path, appending to it.
+func NewRotatingFile(path string, rotation Rotation) (*RotatingFile, error) {
+	switch rotation.Every {
+	case RotateNever, RotateHourly, RotateDaily:
+	default:
+		return nil, fmt.Errorf("unknown rotation period %q", rotation.Every)
+	}
+
+	f := &RotatingFile{path: path, rotation: rotation, millCh: make(chan struct{}, 1)}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	f.millWg.Add(1)
+	go f.mill()
+	return f, nil
+}
+
+func (f *RotatingFile) open() error {
+	file, info, err := openLogFile(f.path)
+	if err != nil {
+		return err
+	}
+	f.setFile(file, info)
+	return nil
+}
+
+func openLogFile(path string) (*os.File, os.FileInfo, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	return file, info, nil
+}
+
+// setFile makes file, described by info, the current file.
+func (f *RotatingFile) setFile(file *os.File, info os.FileInfo) {
+	f.file = file
+	f.size = info.Size()
+	// an existing file belongs to the period it was last written in
+	f.period = f.periodStart(info.ModTime())
+	if info.Size() == 0 {
+		f.period = f.periodStart(time.Now())
+	}
+}
+
+func (f *RotatingFile) periodStart(t time.Time) time.Time {
+	switch f.rotation.Every {
+	case RotateHourly:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	case RotateDaily:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+	return time.Time{}
+}
+
+// Write writes p to the file, rotating it first when needed.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	now := time.Now()
+	rotateTime := f.rotation.Every != RotateNever && f.periodStart(now).After(f.period)
+	rotateSize := f.rotation.MaxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.rotation.MaxSize
+	var rotateErr error
+	if rotateTime || rotateSize {
+		// a failed rotation keeps writing to the current file, it is retried on the next write
+		rotateErr = f.rotate(now)
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+	return n, err
+}
+
+// Rotate rotates the file now.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return os.ErrClosed
+	}
+	return f.rotate(time.Now())
+}
+
+// rotate renames the current file to a backup and opens a new one in its place,
+// the current file is kept when any step fails.
+func (f *RotatingFile) rotate(now time.Time) error {
+	backup, err := f.backupName(now)
+	if err != nil {
+		return err
+	}
+	// rename is atomic, writers never see a partial file
+	if err := os.Rename(f.path, backup); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	file, info, err := openLogFile(f.path)
+	if err != nil {
+		// move the current file back to its path
+		os.Rename(backup, f.path)
+		return err
+	}
+
+	old := f.file
+	f.setFile(file, info)
+	select {
+	case f.millCh <- struct{}{}:
+	default:
+	}
+	return old.Close()
+}
+
+// backupName returns a free backup name for the current file, named after its period for time based rotation.
+func (f *RotatingFile) backupName(now time.Time) (string, error) {
+	dir, prefix, ext := f.nameParts()
+	var timestamp string
+	switch f.rotation.Every {
+	case RotateHourly:
+		timestamp = f.period.Format(hourlyBackupFormat)
+	case RotateDaily:
+		timestamp = f.period.Format(dailyBackupFormat)
+	default:
+		timestamp = now.Format(sizeBackupFormat)
+	}
+
+	// size rotations within a period get a counter
+	for i := 0; i < 10000; i++ {
+		name := prefix + timestamp
+		if i > 0 {
+			name += "." + strconv.Itoa(i)
+		}
+		name = filepath.Join(dir, name+ext)
+		if !fileExists(name) && !fileExists(name+".gz") {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("no free backup name for %s", f.path)
+}
+
+// nameParts splits the path into the directory, the backup name prefix and the extension.
+func (f *RotatingFile) nameParts() (dir, prefix, ext string) {
+	dir = filepath.Dir(f.path)
+	base := filepath.Base(f.path)
+	ext = filepath.Ext(base)
+	return dir, strings.TrimSuffix(base, ext) + "-", ext
+}
+
+func fileExists(name string) bool {
+	_, err := os.Lstat(name)
+	return err == nil
+}
+
+func (f *RotatingFile) mill() {
+	defer f.millWg.Done()
+	for range f.millCh {
+		f.millOnce()
+	}
+}
+
+// millOnce compresses the backups and removes the ones exceeding the retention.
+func (f *RotatingFile) millOnce() {
+	backups, err := f.backups()
+	if err != nil {
+		return
+	}
+
+	var remove []backupInfo
+	if f.rotation.MaxBackups > 0 && len(backups) > f.rotation.MaxBackups {
+		remove = append(remove, backups[f.rotation.MaxBackups:]...)
+		backups = backups[:f.rotation.MaxBackups]
+	}
+	if f.rotation.MaxAge > 0 {
+		cutoff := time.Now().Add(-f.rotation.MaxAge)
+		kept := backups[:0]
+		for _, b := range backups {
+			if b.modTime.Before(cutoff) {
+				remove = append(remove, b)
+			} else {
+				kept = append(kept, b)
+			}
+		}
+		backups = kept
+	}
+
+	for _, b := range remove {
+		os.Remove(b.path)
+	}
+	if f.rotation.Compress {
+		for _, b := range backups {
+			if !strings.HasSuffix(b.path, ".gz") {
+				compressFile(b.path)
+			}
+		}
+	}
+}
+
+type backupInfo struct {
+	path    string
+	modTime time.Time
+}
+
+// backups returns the backups of the file, newest first.
+func (f *RotatingFile) backups() ([]backupInfo, error) {
+	dir, prefix, ext := f.nameParts()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []backupInfo
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !isBackupName(name, prefix, ext) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backupInfo{path: filepath.Join(dir, name), modTime: info.ModTime()})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].modTime.After(backups[j].modTime)
+	})
+	return backups, nil
+}
+
+// isBackupName reports whether name is a backup named by backupName, possibly compressed,
+// other files sharing the prefix like app-access.log for app.log are not.
+func isBackupName(name, prefix, ext string) bool {
+	name, ok := strings.CutPrefix(name, prefix)
+	if !ok {
+		return false
+	}
+	name = strings.TrimSuffix(name, ".gz")
+	if name, ok = strings.CutSuffix(name, ext); !ok {
+		return false
+	}
+	if isBackupTimestamp(name) {
+		return true
+	}
+	// a size rotation within a period has a counter
+	i := strings.LastIndexByte(name, '.')
+	if i < 0 {
+		return false
+	}
+	if n, err := strconv.Atoi(name[i+1:]); err != nil || n <= 0 {
+		return false
+	}
+	return isBackupTimestamp(name[:i])
+}
+
+func isBackupTimestamp(s string) bool {
+	for _, layout := range []string{hourlyBackupFormat, dailyBackupFormat, sizeBackupFormat} {
+		if _, err := time.Parse(layout, s); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// compressFile gzips name into name.gz, the temporary file is renamed so name.gz is never partial.
+func compressFile(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmp := name + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, info.Mode())
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(dst)
+	_, err = io.Copy(gz, src)
+	if err == nil {
+		err = gz.Close()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	// keep the modification time for the retention by age
+	os.Chtimes(tmp, info.ModTime(), info.ModTime())
+	if err := os.Rename(tmp, name+".gz"); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Remove(name)
+}
+
+// Sync commits the written records to disk.
+func (f *RotatingFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return os.ErrClosed
+	}
+	return f.file.Sync()
+}
+
+// Close closes the file and waits for the pending compression and removal of backups.
+func (f *RotatingFile) Close() error {
+	unregisterFile(f)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.millCh != nil {
+		close(f.millCh)
+		f.millWg.Wait()
+		f.millCh = nil
+	}
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+var _ io.WriteCloser = (*RotatingFile)(nil)
@@ -0,0 +1,161 @@
+package slogx
+
+import (
+	"context"
+	"hash/fnv"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// samplingCounters is the number of counters records are hashed to, it bounds the memory of sampling.
+const samplingCounters = 4096
+
+type SamplingHandlerOptions struct {
+	// Tick is the interval the counts are reset at, default to 1s.
+	Tick time.Duration
+	// First is the number of records with the same key logged in each tick, default to 100.
+	First int
+	// Thereafter logs every Thereafter-th record after the first ones in each tick, none when 0.
+	Thereafter int
+	// KeyAttrs are the keys of the attrs which are part of the sampling key,
+	// besides the level and the message, both the record attrs and the ones added by WithAttrs.
+	KeyAttrs []string
+	// PassLevel is the level from which records are never sampled, default to error.
+	PassLevel slog.Leveler
+	// OnSampledOut is called with every dropped record and the number of records dropped
+	// for its key in the current tick.
+	OnSampledOut func(r slog.Record, count int)
+}
+
+// SamplingHandler logs the first records with the same level and message in each tick,
+// then only every Thereafter-th, like the sampling of zap.
+type SamplingHandler struct {
+	state   *samplingState
+	handler slog.Handler
+
+	// keyAttrs are the attrs added by WithAttrs which are part of the sampling key
+	keyAttrs []slog.Attr
+}
+
+// samplingState is shared by a SamplingHandler and the handlers derived from it.
+type samplingState struct {
+	opts     SamplingHandlerOptions
+	counters [samplingCounters]samplingCounter
+}
+
+type samplingCounter struct {
+	resetAt atomic.Int64
+	n       atomic.Int64
+}
+
+func NewSamplingHandler(handler slog.Handler, opts *SamplingHandlerOptions) *SamplingHandler {
+	state := &samplingState{}
+	if opts != nil {
+		state.opts = *opts
+	}
+	if state.opts.Tick <= 0 {
+		state.opts.Tick = time.Second
+	}
+	if state.opts.First <= 0 {
+		state.opts.First = 100
+	}
+	if state.opts.PassLevel == nil {
+		state.opts.PassLevel = slog.LevelError
+	}
+	return &SamplingHandler{state: state, handler: handler}
+}
+
+func (h *SamplingHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return h.handler.Enabled(ctx, l)
+}
+
+func (h *SamplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	opts := &h.state.opts
+	if r.Level >= opts.PassLevel.Level() {
+		return h.handler.Handle(ctx, r)
+	}
+
+	now := r.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	counter := &h.state.counters[h.key(r)%samplingCounters]
+	n := counter.inc(now, opts.Tick)
+	if n <= int64(opts.First) || opts.Thereafter > 0 && (n-int64(opts.First))%int64(opts.Thereafter) == 0 {
+		return h.handler.Handle(ctx, r)
+	}
+
+	if opts.OnSampledOut != nil {
+		dropped := n - int64(opts.First)
+		if opts.Thereafter > 0 {
+			dropped -= dropped / int64(opts.Thereafter)
+		}
+		opts.OnSampledOut(r, int(dropped))
+	}
+	return nil
+}
+
+// key hashes the level, the message and the KeyAttrs of the handler and of r.
+func (h *SamplingHandler) key(r slog.Record) uint32 {
+	hash := fnv.New32a()
+	hash.Write([]byte(r.Level.String()))
+	hash.Write([]byte{0})
+	hash.Write([]byte(r.Message))
+	for _, attr := range h.keyAttrs {
+		hash.Write([]byte{0})
+		hash.Write([]byte(attr.String()))
+	}
+	if len(h.state.opts.KeyAttrs) > 0 {
+		r.Attrs(func(attr slog.Attr) bool {
+			if h.state.isKeyAttr(attr) {
+				hash.Write([]byte{0})
+				hash.Write([]byte(attr.String()))
+			}
+			return true
+		})
+	}
+	return hash.Sum32()
+}
+
+func (s *samplingState) isKeyAttr(attr slog.Attr) bool {
+	for _, key := range s.opts.KeyAttrs {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// inc counts a record in the tick of now, the count restarts in a new tick.
+func (c *samplingCounter) inc(now time.Time, tick time.Duration) int64 {
+	t := now.UnixNano()
+	resetAt := c.resetAt.Load()
+	// only the record winning the swap starts the tick, the others count in it
+	if resetAt <= t && c.resetAt.CompareAndSwap(resetAt, t+tick.Nanoseconds()) {
+		c.n.Store(0)
+	}
+	return c.n.Add(1)
+}
+
+func (h *SamplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	keyAttrs := h.keyAttrs
+	for _, attr := range attrs {
+		if h.state.isKeyAttr(attr) {
+			keyAttrs = append(keyAttrs[:len(keyAttrs):len(keyAttrs)], attr)
+		}
+	}
+	return &SamplingHandler{state: h.state, handler: h.handler.WithAttrs(attrs), keyAttrs: keyAttrs}
+}
+
+func (h *SamplingHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &SamplingHandler{state: h.state, handler: h.handler.WithGroup(name), keyAttrs: h.keyAttrs}
+}
+
+var _ slog.Handler = (*SamplingHandler)(nil)
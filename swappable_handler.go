@@ -0,0 +1,153 @@
+package slogx
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// SwappableHandler sends the records to a handler which can be replaced at runtime,
+// to change the format or the output of a running logger for example.
+// The handlers derived from it with WithAttrs and WithGroup, before or after a swap,
+// send their records to the new handler with their attrs and groups replayed on it.
+// Handle never locks, a derived handler is built once per swap.
+// Swap waits for the records being handled by the previous handler, so it can be closed once Swap returns.
+type SwappableHandler struct {
+	root *swapRoot
+
+	// parent is nil for the root, op is applied on the handler of the parent
+	parent *SwappableHandler
+	op     handlerOp
+	// cache holds the handler derived from the current target
+	cache atomic.Pointer[swapCache]
+}
+
+// swapRoot is shared by a SwappableHandler and the handlers derived from it.
+type swapRoot struct {
+	target atomic.Pointer[swapTarget]
+}
+
+// swapTarget is a swapped in handler, a new one is allocated for every swap.
+type swapTarget struct {
+	handler slog.Handler
+
+	// active counts the Handle calls in flight, drained is closed once it is swapped out and they are done
+	active    atomic.Int64
+	retired   atomic.Bool
+	drained   chan struct{}
+	drainOnce sync.Once
+}
+
+func newSwapTarget(handler slog.Handler) *swapTarget {
+	return &swapTarget{handler: handler, drained: make(chan struct{})}
+}
+
+type swapCache struct {
+	target  *swapTarget
+	handler slog.Handler
+}
+
+// handlerOp is a WithAttrs call, or a WithGroup one when attrs is nil.
+type handlerOp struct {
+	attrs []slog.Attr
+	group string
+}
+
+func NewSwappableHandler(handler slog.Handler) *SwappableHandler {
+	root := &swapRoot{}
+	root.target.Store(newSwapTarget(handler))
+	return &SwappableHandler{root: root}
+}
+
+// Swap replaces the handler, for the handlers derived from h too, and returns the previous one
+// once the records being handled by it are done. It must not be called by the handler.
+func (h *SwappableHandler) Swap(handler slog.Handler) slog.Handler {
+	old := h.root.target.Swap(newSwapTarget(handler))
+	old.retire()
+	return old.handler
+}
+
+// acquire returns the current target, with a Handle call counted in flight on it.
+func (r *swapRoot) acquire() *swapTarget {
+	for {
+		t := r.target.Load()
+		t.active.Add(1)
+		// the target may have been swapped out before the call was counted
+		if r.target.Load() == t {
+			return t
+		}
+		t.release()
+	}
+}
+
+func (t *swapTarget) release() {
+	if t.active.Add(-1) == 0 && t.retired.Load() {
+		t.drainOnce.Do(func() { close(t.drained) })
+	}
+}
+
+// retire waits for the Handle calls in flight on the swapped out t.
+func (t *swapTarget) retire() {
+	t.retired.Store(true)
+	if t.active.Load() == 0 {
+		t.drainOnce.Do(func() { close(t.drained) })
+	}
+	<-t.drained
+}
+
+// Handler returns the current handler, without the attrs and groups of h.
+func (h *SwappableHandler) Handler() slog.Handler {
+	return h.root.target.Load().handler
+}
+
+// current returns the current handler with the attrs and groups of h.
+func (h *SwappableHandler) current() slog.Handler {
+	return h.handlerFor(h.root.target.Load())
+}
+
+// handlerFor returns the handler of t with the attrs and groups of h,
+// concurrent calls may both build it, they build the same.
+func (h *SwappableHandler) handlerFor(t *swapTarget) slog.Handler {
+	if h.parent == nil {
+		return t.handler
+	}
+	if c := h.cache.Load(); c != nil && c.target == t {
+		return c.handler
+	}
+
+	handler := h.parent.handlerFor(t)
+	if h.op.attrs != nil {
+		handler = handler.WithAttrs(h.op.attrs)
+	} else {
+		handler = handler.WithGroup(h.op.group)
+	}
+	h.cache.Store(&swapCache{target: t, handler: handler})
+	return handler
+}
+
+func (h *SwappableHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return h.current().Enabled(ctx, l)
+}
+
+func (h *SwappableHandler) Handle(ctx context.Context, r slog.Record) error {
+	t := h.root.acquire()
+	defer t.release()
+	return h.handlerFor(t).Handle(ctx, r)
+}
+
+func (h *SwappableHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	return &SwappableHandler{root: h.root, parent: h, op: handlerOp{attrs: attrs}}
+}
+
+func (h *SwappableHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &SwappableHandler{root: h.root, parent: h, op: handlerOp{group: name}}
+}
+
+var _ slog.Handler = (*SwappableHandler)(nil)
@@ -0,0 +1,493 @@
+package slogx
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ttys3/slogx/internal"
+)
+
+// SyslogFacilities maps the facility names to their codes, see RFC 5424 section 6.2.1.
+var SyslogFacilities = map[string]int{
+	"kern":     0,
+	"user":     1,
+	"mail":     2,
+	"daemon":   3,
+	"auth":     4,
+	"syslog":   5,
+	"lpr":      6,
+	"news":     7,
+	"uucp":     8,
+	"cron":     9,
+	"authpriv": 10,
+	"ftp":      11,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+const (
+	defaultSyslogFacility = "user"
+	// defaultSyslogSDID uses the enterprise number reserved for documentation, see RFC 5612
+	defaultSyslogSDID = "slog@32473"
+	// defaultSyslogUDPMaxSize is the message size every receiver should accept, see RFC 5426 section 3.2
+	defaultSyslogUDPMaxSize = 2048
+)
+
+// SyslogHandler writes records as syslog messages, each Write to w is one message.
+//
+// With RFC 5424 the attrs are written as the structured data of the message,
+// with RFC 3164 they are appended to the message as logfmt.
+type SyslogHandler struct {
+	mu *sync.Mutex
+	w  io.Writer
+
+	opts     *SyslogHandlerOptions
+	facility int
+	hostname string
+	appName  string
+	pid      int
+
+	// params holds the attrs added by WithAttrs
+	params      []syslogParam
+	groupPrefix string
+	groups      []string
+}
+
+type SyslogHandlerOptions struct {
+	// Facility is the facility name, default to "user".
+	Facility string
+	// AppName default to the program name.
+	AppName string
+	// Hostname default to os.Hostname().
+	Hostname string
+	// RFC3164 writes BSD syslog messages instead of RFC 5424 ones.
+	RFC3164 bool
+	// StructuredDataID is the SD-ID of the RFC 5424 structured data, default to "slog@32473".
+	StructuredDataID string
+	// MaxMessageSize splits the messages longer than it, no limit when 0.
+	MaxMessageSize int
+	slog.HandlerOptions
+}
+
+type syslogParam struct {
+	name  string
+	value string
+}
+
+func NewSyslogHandler(w io.Writer, opts *SyslogHandlerOptions) *SyslogHandler {
+	if opts == nil {
+		opts = &SyslogHandlerOptions{}
+	}
+
+	facility, ok := SyslogFacilities[opts.Facility]
+	if !ok {
+		facility = SyslogFacilities[defaultSyslogFacility]
+	}
+	hostname := opts.Hostname
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+	}
+	appName := opts.AppName
+	if appName == "" {
+		appName = filepath.Base(os.Args[0])
+	}
+
+	return &SyslogHandler{
+		mu:       &sync.Mutex{},
+		w:        w,
+		opts:     opts,
+		facility: facility,
+		hostname: syslogHeaderField(hostname, 255),
+		appName:  syslogHeaderField(appName, 48),
+		pid:      os.Getpid(),
+	}
+}
+
+func (h *SyslogHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.opts.Level != nil {
+		minLevel = h.opts.Level.Level()
+	}
+	return l >= minLevel
+}
+
+func (h *SyslogHandler) Handle(ctx context.Context, r slog.Record) error {
+	params := make([]syslogParam, 0, len(h.params)+r.NumAttrs()+1)
+	appendParam := func(key string, v slog.Value) {
+		params = append(params, syslogParam{name: key, value: logfmtValueString(v)})
+	}
+	if h.opts.AddSource && r.PC != 0 {
+		walkAttr(slog.Any(slog.SourceKey, recordSource(r)), "", nil, h.opts.ReplaceAttr, appendParam)
+	}
+	params = append(params, h.params...)
+	r.Attrs(func(attr slog.Attr) bool {
+		walkAttr(attr, h.groupPrefix, h.groups, h.opts.ReplaceAttr, appendParam)
+		return true
+	})
+
+	pri := h.facility*8 + SyslogSeverity(r.Level)
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+
+	var prefix, contPrefix, body string
+	if h.opts.RFC3164 {
+		prefix = fmt.Sprintf("<%d>%s %s %s[%d]: ", pri, t.Format(time.Stamp), h.hostname, h.appName, h.pid)
+		contPrefix = prefix
+		body = r.Message + syslogLogfmt(params)
+	} else {
+		header := fmt.Sprintf("<%d>1 %s %s %s %d - ", pri, t.Format("2006-01-02T15:04:05.000000Z07:00"), h.hostname, h.appName, h.pid)
+		// the structured data is only sent with the first part of a split message
+		prefix = header + h.structuredData(params) + " "
+		contPrefix = header + "- "
+		body = r.Message
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, m := range splitSyslogMessage(prefix, contPrefix, body, h.opts.MaxMessageSize) {
+		if _, err := h.w.Write([]byte(m)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// structuredData returns the RFC 5424 structured data element of params.
+func (h *SyslogHandler) structuredData(params []syslogParam) string {
+	if len(params) == 0 {
+		return "-"
+	}
+
+	sdID := h.opts.StructuredDataID
+	if sdID == "" {
+		sdID = defaultSyslogSDID
+	}
+
+	buf := internal.NewBuffer()
+	defer buf.Free()
+	buf.WriteByte('[')
+	buf.WriteString(syslogSDName(sdID))
+	for _, p := range params {
+		buf.WriteByte(' ')
+		buf.WriteString(syslogSDName(p.name))
+		buf.WriteString(`="`)
+		for _, r := range p.value {
+			// '"', '\' and ']' must be escaped, see RFC 5424 section 6.3.3
+			if r == '"' || r == '\\' || r == ']' {
+				buf.WriteByte('\\')
+			}
+			buf.WriteString(string(r))
+		}
+		buf.WriteByte('"')
+	}
+	buf.WriteByte(']')
+	return buf.String()
+}
+
+func (h *SyslogHandler) clone() *SyslogHandler {
+	cloned := *h
+	cloned.params = append([]syslogParam(nil), h.params...)
+	cloned.groups = append([]string(nil), h.groups...)
+	return &cloned
+}
+
+func (h *SyslogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	cloned := h.clone()
+	for _, attr := range attrs {
+		walkAttr(attr, h.groupPrefix, h.groups, h.opts.ReplaceAttr, func(key string, v slog.Value) {
+			cloned.params = append(cloned.params, syslogParam{name: key, value: logfmtValueString(v)})
+		})
+	}
+	return cloned
+}
+
+func (h *SyslogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	cloned := h.clone()
+	cloned.groupPrefix += name + "."
+	cloned.groups = append(cloned.groups, name)
+	return cloned
+}
+
+// SyslogSeverity maps a level to a syslog severity,
+// the levels between two severities map to the lower one.
+func SyslogSeverity(l slog.Level) int {
+	switch {
+	case l < slog.LevelInfo:
+		return 7 // debug
+	case l < slog.LevelInfo+2:
+		return 6 // informational
+	case l < slog.LevelWarn:
+		return 5 // notice
+	case l < slog.LevelError:
+		return 4 // warning
+	case l < slog.LevelError+4:
+		return 3 // error
+	case l < slog.LevelError+8:
+		return 2 // critical
+	case l < slog.LevelError+12:
+		return 1 // alert
+	default:
+		return 0 // emergency
+	}
+}
+
+// splitSyslogMessage splits the message into messages of at most maxSize bytes,
+// the first one starts with prefix and the following ones with contPrefix.
+func splitSyslogMessage(prefix, contPrefix, body string, maxSize int) []string {
+	if maxSize <= 0 || len(prefix)+len(body) <= maxSize {
+		return []string{prefix + body}
+	}
+
+	var messages []string
+	for first := true; first || body != ""; first = false {
+		p := contPrefix
+		if first {
+			p = prefix
+		}
+		n := maxSize - len(p)
+		if first && n <= 0 && body != "" {
+			// the structured data alone is too large, send it in its own message
+			messages = append(messages, strings.TrimSuffix(p, " "))
+			continue
+		}
+		chunk := truncateUTF8(body, n)
+		if chunk == "" {
+			// nothing fits, send the rest as is
+			chunk = body
+		}
+		messages = append(messages, p+chunk)
+		body = body[len(chunk):]
+	}
+	return messages
+}
+
+// truncateUTF8 truncates s to at most n bytes, without splitting a rune.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	if n <= 0 {
+		return ""
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+func syslogLogfmt(params []syslogParam) string {
+	if len(params) == 0 {
+		return ""
+	}
+	buf := internal.NewBuffer()
+	defer buf.Free()
+	for _, p := range params {
+		buf.WriteByte(' ')
+		appendLogfmtKey(buf, p.name)
+		buf.WriteByte('=')
+		appendLogfmtValue(buf, p.value)
+	}
+	return buf.String()
+}
+
+// syslogSDName returns name as a valid SD-NAME, at most 32 printable ascii chars except '=', ' ', ']' and '"'.
+func syslogSDName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		if c <= ' ' || c >= 127 || c == '=' || c == ']' || c == '"' {
+			b[i] = '_'
+		}
+	}
+	if len(b) > 32 {
+		b = b[:32]
+	}
+	return string(b)
+}
+
+// syslogHeaderField returns s as a valid header field, at most n printable ascii chars, or "-" if empty.
+func syslogHeaderField(s string, n int) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c <= ' ' || c >= 127 {
+			b[i] = '_'
+		}
+	}
+	if len(b) > n {
+		b = b[:n]
+	}
+	if len(b) == 0 {
+		return "-"
+	}
+	return string(b)
+}
+
+// SyslogWriter sends each Write as one syslog message,
+// it dials lazily and reconnects once when a write fails.
+type SyslogWriter struct {
+	mu sync.Mutex
+
+	network   string // udp, tcp, tls or unix
+	addr      string
+	tlsConfig *tls.Config
+
+	conn net.Conn
+	// stream transports use octet counting framing, see RFC 6587 section 3.4.1
+	stream bool
+}
+
+// NewSyslogWriter returns a writer sending to addr over network, one of udp, tcp, tls or unix.
+func NewSyslogWriter(network, addr string, tlsConfig *tls.Config) (*SyslogWriter, error) {
+	switch network {
+	case "udp", "tcp", "tls", "unix":
+	default:
+		return nil, fmt.Errorf("unsupported syslog network %q", network)
+	}
+	return &SyslogWriter{network: network, addr: addr, tlsConfig: tlsConfig}, nil
+}
+
+func (w *SyslogWriter) dial() error {
+	const timeout = 5 * time.Second
+	var err error
+	switch w.network {
+	case "tls":
+		w.conn, err = tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", w.addr, w.tlsConfig)
+		w.stream = true
+	case "unix":
+		// syslog daemons usually listen on a datagram socket
+		w.conn, err = net.DialTimeout("unixgram", w.addr, timeout)
+		w.stream = false
+		if err != nil {
+			w.conn, err = net.DialTimeout("unix", w.addr, timeout)
+			w.stream = true
+		}
+	default:
+		w.conn, err = net.DialTimeout(w.network, w.addr, timeout)
+		w.stream = w.network == "tcp"
+	}
+	return err
+}
+
+func (w *SyslogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if w.conn == nil {
+			if err = w.dial(); err != nil {
+				w.conn = nil
+				continue
+			}
+		}
+		if err = w.write(p); err == nil {
+			return len(p), nil
+		}
+		// reconnect on failure
+		w.conn.Close()
+		w.conn = nil
+	}
+	return 0, err
+}
+
+func (w *SyslogWriter) write(p []byte) error {
+	if w.stream {
+		frame := make([]byte, 0, len(p)+8)
+		frame = strconv.AppendInt(frame, int64(len(p)), 10)
+		frame = append(frame, ' ')
+		frame = append(frame, p...)
+		p = frame
+	}
+	_, err := w.conn.Write(p)
+	return err
+}
+
+// Close closes the connection, the next Write reconnects.
+func (w *SyslogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// NewSyslogHandlerFromURL returns a syslog handler for an output like syslog+udp://host:514,
+// syslog+tcp://host:601, syslog+tls://host:6514 or syslog+unix:///dev/log.
+// The query can set the facility, app, hostname, rfc (5424 or 3164) and max_size.
+func NewSyslogHandlerFromURL(output string, opts slog.HandlerOptions) (*SyslogHandler, *SyslogWriter, error) {
+	u, err := url.Parse(output)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse syslog output: %w", err)
+	}
+	network, ok := strings.CutPrefix(u.Scheme, "syslog+")
+	if !ok {
+		return nil, nil, fmt.Errorf("syslog output must start with syslog+, got %q", output)
+	}
+	addr := u.Host
+	if network == "unix" {
+		addr = u.Path
+	}
+	w, err := NewSyslogWriter(network, addr, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	q := u.Query()
+	so := &SyslogHandlerOptions{
+		Facility:       q.Get("facility"),
+		AppName:        q.Get("app"),
+		Hostname:       q.Get("hostname"),
+		HandlerOptions: opts,
+	}
+	if so.Facility != "" {
+		if _, ok := SyslogFacilities[so.Facility]; !ok {
+			return nil, nil, fmt.Errorf("unknown syslog facility %q", so.Facility)
+		}
+	}
+	switch rfc := q.Get("rfc"); rfc {
+	case "", "5424":
+	case "3164":
+		so.RFC3164 = true
+	default:
+		return nil, nil, fmt.Errorf("unknown syslog rfc %q", rfc)
+	}
+	if network == "udp" {
+		so.MaxMessageSize = defaultSyslogUDPMaxSize
+	}
+	if maxSize := q.Get("max_size"); maxSize != "" {
+		if so.MaxMessageSize, err = strconv.Atoi(maxSize); err != nil {
+			return nil, nil, fmt.Errorf("invalid syslog max_size %q", maxSize)
+		}
+	}
+	return NewSyslogHandler(w, so), w, nil
+}
+
+var _ slog.Handler = (*SyslogHandler)(nil)
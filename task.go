@@ -0,0 +1,108 @@
+package slogx
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// TaskKey is the group key holding the task attributes of task start/end records.
+const TaskKey = "task"
+
+type taskCtxKey struct{}
+
+type taskEventCtxKey struct{}
+
+// taskEvent marks a record as the start or the end of a task,
+// the cli handler uses it to render a header or a completion line.
+type taskEvent struct {
+	task    *Task
+	end     bool
+	elapsed time.Duration
+	err     error
+}
+
+// Task is a scope of work started by StartTask.
+type Task struct {
+	logger *slog.Logger
+	parent context.Context
+	ctx    context.Context
+	name   string
+	start  time.Time
+	depth  int
+}
+
+// StartTask logs the start of a task named name and returns the task.
+// Records logged with Task.Context() are nested under the task,
+// the cli handler indents them, other handlers leave them as is.
+func StartTask(ctx context.Context, logger *slog.Logger, name string) *Task {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	t := &Task{logger: logger, parent: ctx, name: name, start: time.Now()}
+	if parent := taskFromContext(ctx); parent != nil {
+		t.depth = parent.depth + 1
+	}
+	t.ctx = context.WithValue(ctx, taskCtxKey{}, t)
+
+	t.log(ctx, &taskEvent{task: t}, slog.LevelInfo, slog.Group(TaskKey, slog.String("event", "start")))
+	return t
+}
+
+// Context returns a context carrying the task, use it to log records nested under the task.
+func (t *Task) Context() context.Context {
+	return t.ctx
+}
+
+// Name returns the name of the task.
+func (t *Task) Name() string {
+	return t.name
+}
+
+// End logs the completion of the task with its elapsed time,
+// a non-nil err marks the task as failed and is logged at error level.
+func (t *Task) End(err error) {
+	elapsed := time.Since(t.start)
+	level := slog.LevelInfo
+	attrs := []slog.Attr{slog.Group(TaskKey, slog.String("event", "end"), slog.Duration("duration", elapsed))}
+	if err != nil {
+		level = slog.LevelError
+		attrs = append(attrs, slog.Any("err", err))
+	}
+
+	// the end record belongs to the parent scope, same as the start record
+	t.log(t.parent, &taskEvent{task: t, end: true, elapsed: elapsed, err: err}, level, attrs...)
+}
+
+func (t *Task) log(ctx context.Context, ev *taskEvent, level slog.Level, attrs ...slog.Attr) {
+	ctx = context.WithValue(ctx, taskEventCtxKey{}, ev)
+	if !t.logger.Enabled(ctx, level) {
+		return
+	}
+
+	// skip [runtime.Callers, this function, StartTask or End]
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	r := slog.NewRecord(time.Now(), level, t.name, pcs[0])
+	r.AddAttrs(attrs...)
+	_ = t.logger.Handler().Handle(ctx, r)
+}
+
+func taskFromContext(ctx context.Context) *Task {
+	t, _ := ctx.Value(taskCtxKey{}).(*Task)
+	return t
+}
+
+// taskDepth returns the indentation depth of a record logged with ctx,
+// and the task event if the record is a task start or end record.
+func taskDepth(ctx context.Context) (int, *taskEvent) {
+	if ev, ok := ctx.Value(taskEventCtxKey{}).(*taskEvent); ok {
+		return ev.task.depth, ev
+	}
+	if t := taskFromContext(ctx); t != nil {
+		return t.depth + 1, nil
+	}
+	return 0, nil
+}
@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ttys3/slogx"
+)
+
+// gateWriter blocks the writes until the gate is opened.
+type gateWriter struct {
+	gate chan struct{}
+	mu   sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (w *gateWriter) Write(p []byte) (int, error) {
+	<-w.gate
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *gateWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestSlogxWithAsync(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slogx.NewLogger(slogx.WithWriter(&buf),
+		slogx.WithFormat("logfmt"),
+		slogx.WithDisableTime(),
+		slogx.WithDisableSource(),
+		slogx.WithAsync(slogx.AsyncHandlerOptions{BatchSize: 4}))
+
+	l := logger.With("name", "Al")
+	for i := 0; i < 10; i++ {
+		l.Info("hello", "i", i)
+	}
+	if err := logger.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var want []string
+	for i := 0; i < 10; i++ {
+		want = append(want, fmt.Sprintf("level=INFO msg=hello name=Al i=%d", i))
+	}
+	checkLogOutput(t, buf.String(), strings.Join(want, "~"))
+}
+
+func asyncDropped(t *testing.T, out string) (records, dropped int) {
+	t.Helper()
+	records = strings.Count(out, "msg=hello")
+	if m := regexp.MustCompile(`dropped=(\d+)`).FindStringSubmatch(out); m != nil {
+		dropped, _ = strconv.Atoi(m[1])
+	}
+	return records, dropped
+}
+
+func TestAsyncHandlerOverflow(t *testing.T) {
+	policies := map[string]slogx.OverflowPolicy{
+		"drop newest":      slogx.OverflowDropNewest,
+		"drop oldest":      slogx.OverflowDropOldest,
+		"drop below level": slogx.OverflowDropBelowLevel,
+	}
+	for name, policy := range policies {
+		t.Run(name, func(t *testing.T) {
+			w := &gateWriter{gate: make(chan struct{})}
+			h := slogx.NewAsyncHandler(slogx.NewLogfmtHandler(w, nil), &slogx.AsyncHandlerOptions{
+				QueueSize:          2,
+				Overflow:           policy,
+				DropReportInterval: -1,
+			})
+			logger := slog.New(h)
+			for i := 0; i < 10; i++ {
+				logger.Info("hello", "i", i)
+			}
+			close(w.gate)
+			if err := h.Close(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			out := w.String()
+			records, dropped := asyncDropped(t, out)
+			if records+dropped != 10 || dropped == 0 {
+				t.Fatalf("%d records and %d dropped, want 10 in total:\n%s", records, dropped, out)
+			}
+			if policy == slogx.OverflowDropOldest && !strings.Contains(out, "i=9") {
+				t.Fatalf("the newest record is dropped:\n%s", out)
+			}
+			if err := logger.Handler().Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "closed", 0)); !errors.Is(err, slogx.ErrAsyncClosed) {
+				t.Fatalf("Handle() after Close = %v, want %v", err, slogx.ErrAsyncClosed)
+			}
+		})
+	}
+}
+
+func TestAsyncHandlerFlushTimeout(t *testing.T) {
+	w := &gateWriter{gate: make(chan struct{})}
+	h := slogx.NewAsyncHandler(slogx.NewLogfmtHandler(w, nil), nil)
+	slog.New(h).Info("hello")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := h.Flush(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Flush() = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	close(w.gate)
+	if err := h.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(w.String(), "msg=hello") {
+		t.Fatalf("record not written after Flush: %q", w.String())
+	}
+	if err := h.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
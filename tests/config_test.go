@@ -0,0 +1,221 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ttys3/slogx"
+)
+
+func TestLoadConfig(t *testing.T) {
+	files := map[string]string{
+		"log.json": `{"level":"debug","format":"logfmt","output":"discard","disable_time":true,
+			"sinks":[{"format":"json","output":"stdout","level":"warn"}],
+			"sampling":{"tick":"2s","first":10},
+			"redact":{"keys":["password"],"patterns":["default"],"replace":"partial:4"}}`,
+		"log.yaml": `
+level: debug
+format: logfmt
+output: discard
+disable_time: true
+sinks:
+  - format: json
+    output: stdout
+    level: warn
+sampling:
+  tick: 2s
+  first: 10
+redact:
+  keys: [password]
+  patterns: [default]
+  replace: partial:4
+`,
+		"log.toml": `
+level = "debug"
+format = "logfmt"
+output = "discard"
+disable_time = true
+
+[[sinks]]
+format = "json"
+output = "stdout"
+level = "warn"
+
+[sampling]
+tick = "2s"
+first = 10
+
+[redact]
+keys = ["password"]
+patterns = ["default"]
+replace = "partial:4"
+`,
+	}
+	dir := t.TempDir()
+	for name, data := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			c, err := slogx.LoadConfig(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Level != "debug" || c.Format != "logfmt" || !c.DisableTime || len(c.Sinks) != 1 || c.Sinks[0].Level != "warn" ||
+				c.Sampling == nil || time.Duration(c.Sampling.Tick) != 2*time.Second || c.Redact == nil || c.Redact.Replace != "partial:4" {
+				t.Fatalf("LoadConfig() = %+v", c)
+			}
+			if err := c.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := c.NewHandler(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestConfigInvalid(t *testing.T) {
+	_, err := slogx.DecodeConfig(strings.NewReader(`{"levle":"debug"}`), "json")
+	if err == nil || !strings.Contains(err.Error(), "levle") {
+		t.Fatalf("DecodeConfig() with an unknown field error = %v", err)
+	}
+	_, err = slogx.DecodeConfig(strings.NewReader(`levle: debug`), "yaml")
+	if err == nil {
+		t.Fatal("DecodeConfig() with an unknown yaml field succeeded")
+	}
+
+	c := &slogx.Config{Level: "verbose", Sinks: []slogx.SinkConfig{{Format: "xml"}}, Redact: &slogx.RedactConfig{Replace: "partial"}}
+	err = c.Validate()
+	if err == nil || !strings.Contains(err.Error(), "partial") {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	c.Redact = nil
+	err = c.Validate()
+	if err == nil || !strings.Contains(err.Error(), `unknown level "verbose"`) || !strings.Contains(err.Error(), `unknown format "xml"`) {
+		t.Fatalf("Validate() error = %v", err)
+	}
+}
+
+func TestWatchConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "log.yaml")
+	first := filepath.Join(dir, "first.log")
+	second := filepath.Join(dir, "second.log")
+	writeConfig := func(output, level string) {
+		t.Helper()
+		data := "format: logfmt\ndisable_time: true\ndisable_source: true\noutput: " + output + "\nlevel: " + level + "\n"
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig(first, "info")
+
+	logger, err := slogx.WatchConfig(path, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close(context.Background())
+
+	l := logger.With("name", "Al").WithGroup("req")
+	l.Debug("dropped", "id", 1)
+	l.Info("first", "id", 1)
+
+	// the mtime may not change within the same tick on some file systems
+	time.Sleep(20 * time.Millisecond)
+	writeConfig(second, "debug")
+	// the level changes before the handler is swapped, wait for the reload to be logged
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if data, _ := os.ReadFile(second); strings.Contains(string(data), "log config reloaded") {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	l.Debug("second", "id", 2)
+
+	data, err := os.ReadFile(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkLogOutput(t, string(data), `level=INFO msg=first name=Al req.id=1`)
+	data, err = os.ReadFile(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkLogOutput(t, string(data), `level=INFO msg="log config reloaded" path=\S+~level=DEBUG msg=second name=Al req.id=2`)
+}
+
+func TestWatchConfigReloadUnderLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "log.yaml")
+	outputs := []string{filepath.Join(dir, "a.log"), filepath.Join(dir, "b.log")}
+	writeConfig := func(output string) {
+		t.Helper()
+		data := "format: logfmt\ndisable_time: true\ndisable_source: true\noutput: " + output + "\n"
+		// renamed in place, the watcher never reads a partial config
+		if err := os.WriteFile(path+".tmp", []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(path+".tmp", path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig(outputs[0])
+
+	logger, err := slogx.WatchConfig(path, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var written atomic.Int64
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				logger.Info("record")
+				written.Add(1)
+			}
+		}()
+	}
+
+	// every reload closes the output the records in flight are written to
+	for i := 1; i <= 10; i++ {
+		// the mtime may not change within the same tick on some file systems
+		time.Sleep(20 * time.Millisecond)
+		writeConfig(outputs[i%2])
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(stop)
+	wg.Wait()
+	if err := logger.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var logged int
+	for _, output := range outputs {
+		data, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		logged += strings.Count(string(data), "msg=record")
+	}
+	if int64(logged) != written.Load() {
+		t.Fatalf("%d records logged, want %d", logged, written.Load())
+	}
+}
@@ -0,0 +1,193 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ttys3/slogx"
+)
+
+func TestDedupHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := slogx.NewDedupHandler(slogx.NewLogfmtHandler(&buf, &slogx.LogfmtHandlerOptions{
+		HandlerOptions: slog.HandlerOptions{ReplaceAttr: removeTime},
+	}), nil)
+	logger := slog.New(h)
+
+	for i := 0; i < 3; i++ {
+		logger.Warn("retrying", "attempt", 1)
+	}
+	// different attrs are a different record
+	logger.Warn("retrying", "attempt", 2)
+	logger.With("name", "Al").Warn("retrying", "attempt", 2)
+	logger.With("name", "Al").Warn("retrying", "attempt", 2)
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	checkLogOutput(t, buf.String(), `level=WARN msg=retrying attempt=1~`+
+		`level=WARN msg=retrying attempt=1 repeated=2 first_seen=\S+ last_seen=\S+~`+
+		`level=WARN msg=retrying attempt=2~`+
+		`level=WARN msg=retrying name=Al attempt=2~`+
+		`level=WARN msg=retrying name=Al attempt=2 repeated=1 first_seen=\S+ last_seen=\S+`)
+}
+
+func removeTime(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) == 0 && a.Key == slog.TimeKey {
+		return slog.Attr{}
+	}
+	return a
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestDedupHandlerWindow(t *testing.T) {
+	var buf syncBuffer
+	h := slogx.NewDedupHandler(slogx.NewLogfmtHandler(&buf, nil), &slogx.DedupHandlerOptions{Window: 20 * time.Millisecond})
+	logger := slog.New(h)
+
+	logger.Warn("retrying")
+	logger.Warn("retrying")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) && !strings.Contains(buf.String(), "repeated=1") {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !strings.Contains(buf.String(), "repeated=1") {
+		t.Fatalf("no summary after the window closed:\n%s", buf.String())
+	}
+
+	// a new window starts
+	logger.Warn("retrying")
+	if got := strings.Count(buf.String(), "msg=retrying"); got != 3 {
+		t.Fatalf("%d records, want 3:\n%s", got, buf.String())
+	}
+}
+
+func TestSlogxWithDedupFlushedOnClose(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slogx.NewLogger(slogx.WithWriter(&buf),
+		slogx.WithFormat("logfmt"),
+		slogx.WithDisableTime(),
+		slogx.WithDisableSource(),
+		slogx.WithDedup(slogx.DedupHandlerOptions{}))
+
+	for i := 0; i < 3; i++ {
+		logger.Warn("retrying", "attempt", 1)
+	}
+	if err := logger.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	checkLogOutput(t, buf.String(), `level=WARN msg=retrying attempt=1~`+
+		`level=WARN msg=retrying attempt=1 repeated=2 first_seen=\S+ last_seen=\S+`)
+}
+
+// enterWriter signals the writes and blocks them until the gate is opened.
+type enterWriter struct {
+	entered chan struct{}
+	gate    chan struct{}
+}
+
+func (w *enterWriter) Write(p []byte) (int, error) {
+	w.entered <- struct{}{}
+	<-w.gate
+	return len(p), nil
+}
+
+func TestDedupHandlerSlowOutput(t *testing.T) {
+	w := &enterWriter{entered: make(chan struct{}, 1), gate: make(chan struct{})}
+	logger := slog.New(slogx.NewDedupHandler(slogx.NewLogfmtHandler(w, nil), nil))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		logger.Info("first")
+	}()
+	<-w.entered
+
+	// the repeats are counted while the output is blocked
+	repeated := make(chan struct{})
+	go func() {
+		defer close(repeated)
+		logger.Info("first")
+	}()
+	select {
+	case <-repeated:
+	case <-time.After(5 * time.Second):
+		t.Fatal("a repeat is blocked by the output of the first record")
+	}
+	close(w.gate)
+	<-done
+}
+
+// gateFirstHandler blocks the first record until the gate is opened, the others go through.
+type gateFirstHandler struct {
+	slog.Handler
+	first   *atomic.Bool
+	entered chan struct{}
+	gate    chan struct{}
+}
+
+func (h gateFirstHandler) Handle(ctx context.Context, r slog.Record) error {
+	if h.first.CompareAndSwap(false, true) {
+		close(h.entered)
+		<-h.gate
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func TestDedupHandlerSummaryAfterFirst(t *testing.T) {
+	var buf syncBuffer
+	h := gateFirstHandler{
+		Handler: slogx.NewLogfmtHandler(&buf, &slogx.LogfmtHandlerOptions{HandlerOptions: slog.HandlerOptions{ReplaceAttr: removeTime}}),
+		first:   &atomic.Bool{},
+		entered: make(chan struct{}),
+		gate:    make(chan struct{}),
+	}
+	logger := slog.New(slogx.NewDedupHandler(h, nil))
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		logger.Info("first")
+	}()
+	<-h.entered
+	logger.Info("first")
+
+	// the summary of the repeat waits for the first record, which is still being handled
+	go func() {
+		defer wg.Done()
+		logger.Info("second")
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(h.gate)
+	wg.Wait()
+
+	checkLogOutput(t, buf.String(), `level=INFO msg=first~`+
+		`level=INFO msg=first repeated=1 first_seen=\S+ last_seen=\S+~`+
+		`level=INFO msg=second`)
+}
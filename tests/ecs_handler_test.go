@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/ttys3/slogx"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type stackError struct{ msg string }
+
+func (e *stackError) Error() string { return e.msg }
+
+func (e *stackError) Format(s fmt.State, verb rune) {
+	io.WriteString(s, e.msg)
+	if s.Flag('+') {
+		io.WriteString(s, "\nmain.main\n\t/app/main.go:10")
+	}
+}
+
+func TestSlogxECS(t *testing.T) {
+	var buf bytes.Buffer
+	mw := io.MultiWriter(&buf, os.Stderr)
+	slog.SetDefault(slogx.New(slogx.WithFormat("ecs"), slogx.WithWriter(mw)))
+
+	spanCtx := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1, 2, 3},
+		SpanID:  trace.SpanID{4, 5, 6},
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), spanCtx)
+
+	l := slog.With("service", "api").WithGroup("req").With("id", 7)
+	l.ErrorContext(ctx, "request failed", "status", 500, "err", errors.New("boom"))
+	checkLogOutput(t, buf.String(), `{"@timestamp":"\S+","log.level":"error","log.origin":{"file.name":"tests/ecs_handler_test.go","file.line":\d+},"message":"request failed","service":"api","ecs.version":"8.11.0","trace.id":"01020300000000000000000000000000","span.id":"0405060000000000","req":{"id":7,"status":500,"err":"boom"}}`)
+	buf.Reset()
+
+	slog.Warn("retry", "err", &stackError{"timeout"})
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	wantErr := map[string]any{
+		"message":     "timeout",
+		"type":        "*tests.stackError",
+		"stack_trace": "timeout\nmain.main\n\t/app/main.go:10",
+	}
+	if fmt.Sprint(got["error"]) != fmt.Sprint(wantErr) || got["log.level"] != "warn" || got["message"] != "retry" {
+		t.Errorf("got %v", got)
+	}
+	if _, ok := got["trace.id"]; ok {
+		t.Errorf("unexpected trace.id without a span: %v", got)
+	}
+}
+
+func TestSlogxECSErrors(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slogx.New(slogx.WithFormat("ecs"), slogx.WithWriter(&buf), slogx.WithDisableTime(), slogx.WithDisableSource())
+
+	logger.Error("x", "cause", errors.New("first"), "wrapped", errors.New("second"))
+	checkLogOutput(t, buf.String(), `{"log.level":"error","message":"x","ecs.version":"8.11.0","cause":"first","wrapped":"second"}`)
+	buf.Reset()
+
+	logger.Error("x", "err", errors.New("first"), "cause", errors.New("second"))
+	checkLogOutput(t, buf.String(), `{"log.level":"error","message":"x","ecs.version":"8.11.0","error":{"message":"first","type":"\*errors.errorString"},"cause":"second"}`)
+	buf.Reset()
+
+	// the first error wins, the error key appears once
+	logger.Error("x", "err", errors.New("first"), "error", errors.New("second"))
+	checkLogOutput(t, buf.String(), `{"log.level":"error","message":"x","ecs.version":"8.11.0","error":{"message":"first","type":"\*errors.errorString"},"err":"second"}`)
+	buf.Reset()
+
+	logger.With("error", errors.New("first")).Error("x", "error", errors.New("second"))
+	checkLogOutput(t, buf.String(), `{"log.level":"error","message":"x","error":{"message":"first","type":"\*errors.errorString"},"ecs.version":"8.11.0","err":"second"}`)
+}
@@ -0,0 +1,56 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/ttys3/slogx"
+)
+
+func TestSlogxWithEnv(t *testing.T) {
+	t.Setenv("MYAPP_LEVEL", "DEBUG")
+	t.Setenv("MYAPP_FORMAT", "logfmt")
+	t.Setenv("MYAPP_DISABLE_TIME", "true")
+	t.Setenv("MYAPP_DISABLE_SOURCE", "1")
+
+	var buf bytes.Buffer
+	logger := slogx.New(slogx.WithWriter(&buf), slogx.WithEnv("MYAPP"))
+	logger.Debug("from env")
+	checkLogOutput(t, buf.String(), `level=DEBUG msg="from env"`)
+
+	// explicit options take precedence, whatever their order
+	buf.Reset()
+	logger = slogx.New(slogx.WithFormat("text"), slogx.WithEnv("MYAPP"), slogx.WithWriter(&buf))
+	logger.Debug("explicit format")
+	checkLogOutput(t, buf.String(), `level=DEBUG msg="explicit format"`)
+	buf.Reset()
+	logger = slogx.New(slogx.WithEnv("MYAPP"), slogx.WithLevel("info"), slogx.WithWriter(&buf))
+	logger.Debug("explicit level")
+	if buf.Len() != 0 {
+		t.Fatalf("debug record logged: %q", buf.String())
+	}
+}
+
+func TestSlogxWithEnvInvalid(t *testing.T) {
+	t.Setenv("SLOGX_TRACING", "maybe")
+	t.Setenv("SLOGX_FORMAT", "xml")
+
+	_, err := slogx.NewE(slogx.WithEnv(""))
+	if err == nil || !strings.Contains(err.Error(), "SLOGX_TRACING") || !strings.Contains(err.Error(), `unknown format "xml"`) {
+		t.Fatalf("NewE() error = %v", err)
+	}
+}
+
+func TestInitDefaultEnv(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	t.Setenv("SLOGX_LEVEL", "error")
+	t.Setenv("SLOGX_OUTPUT", "discard")
+
+	slogx.InitDefault()
+	if slog.Default().Enabled(context.Background(), slog.LevelWarn) {
+		t.Fatal("warn is enabled, SLOGX_LEVEL is ignored")
+	}
+}
@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ttys3/slogx"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSlogxGCP(t *testing.T) {
+	var buf bytes.Buffer
+	mw := io.MultiWriter(&buf, os.Stderr)
+	slog.SetDefault(slogx.New(slogx.WithFormat("gcp"), slogx.WithWriter(mw), slogx.WithDisableTime()))
+
+	slog.Warn("hello", slog.Group("labels", "env", "prod", "shard", 3), "user", "Al")
+	checkLogOutput(t, buf.String(), `{"severity":"WARNING","logging.googleapis.com/sourceLocation":{"file":"tests/gcp_handler_test.go","line":"\d+"},"message":"hello","logging.googleapis.com/labels":{"env":"prod","shard":"3"},"user":"Al"}`)
+	buf.Reset()
+
+	req := httptest.NewRequest("GET", "http://example.com/path?q=1", nil)
+	req.Header.Set("User-Agent", "curl/8.0")
+	slog.Info("request", "req", req)
+	checkLogOutput(t, buf.String(), `{"severity":"INFO",.*"message":"request","httpRequest":{"requestMethod":"GET","requestUrl":"http://example.com/path\?q=1","protocol":"HTTP/1.1","userAgent":"curl/8.0","remoteIp":"192.0.2.1:1234"}}`)
+	buf.Reset()
+
+	slog.Info("served", "req", slogx.HTTPRequest{Request: req, Status: 200, ResponseSize: 42, Latency: 1500 * time.Millisecond})
+	checkLogOutput(t, buf.String(), `.*"httpRequest":{.*"status":200,"responseSize":"42","latency":"1.500000000s"}}`)
+	buf.Reset()
+}
+
+func TestSlogxGCPTrace(t *testing.T) {
+	var buf bytes.Buffer
+	mw := io.MultiWriter(&buf, os.Stderr)
+	handler := slogx.NewGCPHandler(mw, &slogx.GCPHandlerOptions{ProjectID: "my-project"})
+	logger := slog.New(handler).WithGroup("req").With("id", 7)
+
+	spanCtx := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1, 2, 3},
+		SpanID:     trace.SpanID{4, 5, 6},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), spanCtx)
+	logger.Log(ctx, slog.LevelError+4, "fatal", "code", 1)
+	checkLogOutput(t, buf.String(), `{"time":"\S+","severity":"CRITICAL","message":"fatal","logging.googleapis.com/trace":"projects/my-project/traces/01020300000000000000000000000000","logging.googleapis.com/spanId":"0405060000000000","logging.googleapis.com/trace_sampled":true,"req":{"id":7,"code":1}}`)
+}
+
+func TestGCPSeverity(t *testing.T) {
+	for l, want := range map[slog.Level]string{
+		slog.LevelDebug - 4:  "DEBUG",
+		slog.LevelDebug:      "DEBUG",
+		slog.LevelInfo:       "INFO",
+		slog.LevelInfo + 2:   "NOTICE",
+		slog.LevelWarn:       "WARNING",
+		slog.LevelError:      "ERROR",
+		slog.LevelError + 4:  "CRITICAL",
+		slog.LevelError + 8:  "ALERT",
+		slog.LevelError + 12: "EMERGENCY",
+	} {
+		if got := slogx.GCPSeverity(l); got != want {
+			t.Errorf("GCPSeverity(%v) = %s, want %s", l, got, want)
+		}
+	}
+}
@@ -1,6 +1,6 @@
 module github.com/ttys3/slogx/tests
 
-go 1.21
+go 1.22
 
 replace github.com/ttys3/slogx => ../
 
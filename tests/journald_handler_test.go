@@ -0,0 +1,167 @@
+//go:build linux
+
+package tests
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ttys3/slogx"
+)
+
+// parseJournaldEntry parses a native journal protocol entry into its fields.
+func parseJournaldEntry(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	fields := map[string]string{}
+	for len(data) > 0 {
+		nl := bytes.IndexByte(data, '\n')
+		if nl < 0 {
+			t.Fatalf("missing newline in %q", data)
+		}
+		line := string(data[:nl])
+		if name, value, ok := strings.Cut(line, "="); ok {
+			fields[name] = value
+			data = data[nl+1:]
+			continue
+		}
+		// binary field: name, newline, little endian uint64 size, value, newline
+		size := binary.LittleEndian.Uint64(data[nl+1 : nl+9])
+		fields[line] = string(data[nl+9 : nl+9+int(size)])
+		data = data[nl+9+int(size)+1:]
+	}
+	return fields
+}
+
+func listenJournald(t *testing.T) *net.UnixConn {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "journal.socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestSlogxJournald(t *testing.T) {
+	conn := listenJournald(t)
+	logger := slogx.New(slogx.WithOutput("journald://" + conn.LocalAddr().String()))
+
+	logger.With("user", "tobi").WithGroup("req").Warn("upload retry", "file", "a.png", "_trusted", 1, "stack", "line1\nline2")
+
+	buf := make([]byte, 4096)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := parseJournaldEntry(t, buf[:n])
+	want := map[string]string{
+		"MESSAGE":           "upload retry",
+		"PRIORITY":          "4",
+		"SYSLOG_IDENTIFIER": filepath.Base(os.Args[0]),
+		"CODE_FILE":         fields["CODE_FILE"],
+		"CODE_LINE":         fields["CODE_LINE"],
+		"CODE_FUNC":         "github.com/ttys3/slogx/tests.TestSlogxJournald",
+		"USER":              "tobi",
+		"REQ_FILE":          "a.png",
+		"REQ__TRUSTED":      "1",
+		"REQ_STACK":         "line1\nline2",
+	}
+	if !strings.HasSuffix(fields["CODE_FILE"], "journald_handler_test.go") || fields["CODE_LINE"] == "" {
+		t.Errorf("unexpected source %s:%s", fields["CODE_FILE"], fields["CODE_LINE"])
+	}
+	if len(fields) != len(want) {
+		t.Errorf("got %q, want %q", fields, want)
+	}
+	for k, v := range want {
+		if fields[k] != v {
+			t.Errorf("%s: got %q, want %q", k, fields[k], v)
+		}
+	}
+}
+
+func TestSlogxJournaldMemfd(t *testing.T) {
+	conn := listenJournald(t)
+	logger := slog.New(slogx.NewJournaldHandler(&slogx.JournaldHandlerOptions{SocketPath: conn.LocalAddr().String()}))
+
+	large := strings.Repeat("x", 4<<20)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		logger.Info("large", "data", large)
+	}()
+
+	buf := make([]byte, 64)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-done
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("expected a memfd, got %v %v", msgs, err)
+	}
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	if err != nil || len(fds) != 1 {
+		t.Fatalf("expected a memfd, got %v %v", fds, err)
+	}
+	file := os.NewFile(uintptr(fds[0]), "memfd")
+	defer file.Close()
+	// the fd shares the offset of the sender, which wrote the entry
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := parseJournaldEntry(t, data)
+	if fields["MESSAGE"] != "large" || fields["DATA"] != large {
+		t.Errorf("unexpected fields MESSAGE=%q len(DATA)=%d", fields["MESSAGE"], len(fields["DATA"]))
+	}
+}
+
+func TestSlogxPriorityPrefix(t *testing.T) {
+	var buf bytes.Buffer
+	mw := io.MultiWriter(&buf, os.Stderr)
+	logger := slogx.New(slogx.WithFormat("pretty-json"),
+		slogx.WithPriorityPrefix(),
+		slogx.WithDisableColor(),
+		slogx.WithDisableSource(),
+		slogx.WithDisableTime(),
+		slogx.WithWriter(mw))
+
+	logger.Error("oops", "status", 500)
+	checkLogOutput(t, buf.String(), `<3>{~<3>  "level": "ERROR",~<3>  "msg": "oops",~<3>  "status": 500~<3>}`)
+	buf.Reset()
+
+	logger.With("user", "tobi").Info("hello")
+	checkLogOutput(t, buf.String(), `<6>{~<6>  "level": "INFO",~<6>  "msg": "hello",~<6>  "user": "tobi"~<6>}`)
+}
+
+func TestJournaldFieldName(t *testing.T) {
+	for key, want := range map[string]string{
+		"user":                  "USER",
+		"req.user-agent":        "REQ_USER_AGENT",
+		"_SYSTEMD_UNIT":         "SYSTEMD_UNIT",
+		"1st":                   "X_1ST",
+		"":                      "X_",
+		strings.Repeat("a", 70): strings.Repeat("A", 64),
+	} {
+		if got := slogx.JournaldFieldName(key); got != want {
+			t.Errorf("JournaldFieldName(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
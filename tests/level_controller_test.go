@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ttys3/slogx"
+)
+
+func levelRequest(t *testing.T, h http.Handler, method, contentType, body string) (int, map[string]any) {
+	t.Helper()
+	req := httptest.NewRequest(method, "/log/level", strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	var got map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON response %q: %v", rec.Body.String(), err)
+	}
+	return rec.Code, got
+}
+
+func TestLevelControllerHTTP(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slogx.NewLogger(slogx.WithWriter(&buf), slogx.WithFormat("logfmt"))
+	defer logger.Close(context.Background())
+	levels := logger.Levels()
+
+	code, got := levelRequest(t, levels, http.MethodGet, "", "")
+	if code != http.StatusOK || got["level"] != "info" {
+		t.Fatalf("GET = %d %v", code, got)
+	}
+
+	code, got = levelRequest(t, levels, http.MethodPut, "application/x-www-form-urlencoded", "level=debug")
+	if code != http.StatusOK || got["level"] != "debug" {
+		t.Fatalf("PUT form = %d %v", code, got)
+	}
+	logger.Debug("debug enabled")
+	if !strings.Contains(buf.String(), "debug enabled") {
+		t.Fatalf("debug record dropped: %q", buf.String())
+	}
+
+	code, got = levelRequest(t, levels, http.MethodPost, "application/json", `{"level":"error"}`)
+	if code != http.StatusOK || got["level"] != "error" || levels.Level() != slog.LevelError {
+		t.Fatalf("POST json = %d %v", code, got)
+	}
+
+	code, got = levelRequest(t, levels, http.MethodPut, "application/json", `{"level":"verbose"}`)
+	if code != http.StatusBadRequest || got["error"] == nil {
+		t.Fatalf("PUT unknown level = %d %v", code, got)
+	}
+	code, _ = levelRequest(t, levels, http.MethodDelete, "", "")
+	if code != http.StatusMethodNotAllowed {
+		t.Fatalf("DELETE = %d", code)
+	}
+}
+
+func TestLevelControllerTTL(t *testing.T) {
+	var levels slogx.LevelController
+	logger := slogx.New(slogx.WithWriter(&bytes.Buffer{}), slogx.WithLevelController(&levels))
+
+	code, got := levelRequest(t, &levels, http.MethodPut, "application/json", `{"level":"debug","ttl":"20ms"}`)
+	if code != http.StatusOK || got["reverts_at"] == nil {
+		t.Fatalf("PUT with ttl = %d %v", code, got)
+	}
+	if !logger.Enabled(context.Background(), slog.LevelDebug) {
+		t.Fatal("debug is not enabled")
+	}
+	// a second temporary change still reverts to the initial level
+	levels.SetLevelFor(slog.LevelWarn, 20*time.Millisecond)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) && levels.Level() != slog.LevelInfo {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if levels.Level() != slog.LevelInfo {
+		t.Fatalf("level = %v after the ttl, want %v", levels.Level(), slog.LevelInfo)
+	}
+}
+
+func TestLevelControllerPackages(t *testing.T) {
+	var levels slogx.LevelController
+	var buf bytes.Buffer
+	logger := slogx.New(slogx.WithWriter(&buf),
+		slogx.WithFormat("logfmt"),
+		slogx.WithPackageLevels("info,github.com/acme=debug"),
+		slogx.WithLevelController(&levels))
+
+	code, got := levelRequest(t, &levels, http.MethodPut, "application/json", `{"packages":"error,github.com/ttys3/slogx/tests=debug"}`)
+	if code != http.StatusOK || got["level"] != "error" || got["packages"] != "error,github.com/ttys3/slogx/tests=debug" {
+		t.Fatalf("PUT packages = %d %v", code, got)
+	}
+	logger.Debug("hello")
+	if !strings.Contains(buf.String(), "msg=hello") {
+		t.Fatalf("debug record of the tests package dropped: %q", buf.String())
+	}
+}
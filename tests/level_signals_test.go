@@ -0,0 +1,79 @@
+//go:build !windows
+
+package tests
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ttys3/slogx"
+)
+
+func waitLevel(t *testing.T, levels *slogx.LevelController, want slog.Level) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) && levels.Level() != want {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := levels.Level(); got != want {
+		t.Fatalf("level = %v, want %v", got, want)
+	}
+}
+
+func TestSlogxWithLevelSignals(t *testing.T) {
+	var buf syncBuffer
+	logger := slogx.NewLogger(slogx.WithWriter(&buf),
+		slogx.WithFormat("logfmt"),
+		slogx.WithDisableTime(),
+		slogx.WithDisableSource(),
+		slogx.WithLevel("warn"),
+		slogx.WithLevelSignals())
+	defer logger.Close(context.Background())
+
+	for _, want := range []slog.Level{slog.LevelInfo, slog.LevelDebug, slog.LevelWarn, slog.LevelInfo} {
+		syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+		waitLevel(t, logger.Levels(), want)
+	}
+	syscall.Kill(os.Getpid(), syscall.SIGUSR2)
+	waitLevel(t, logger.Levels(), slog.LevelWarn)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) && strings.Count(buf.String(), "\n") < 5 {
+		time.Sleep(5 * time.Millisecond)
+	}
+	checkLogOutput(t, buf.String(), `level=INFO msg="log level changed" from=warn to=info signal="user defined signal 1"~`+
+		`level=INFO msg="log level changed" from=info to=debug signal="user defined signal 1"~`+
+		`level=INFO msg="log level changed" from=debug to=warn signal="user defined signal 1"~`+
+		`level=INFO msg="log level changed" from=warn to=info signal="user defined signal 1"~`+
+		`level=INFO msg="log level changed" from=info to=warn signal="user defined signal 2"`)
+}
+
+func TestSlogxLevelSignalsBypassFilters(t *testing.T) {
+	var buf syncBuffer
+	logger := slogx.NewLogger(slogx.WithWriter(&buf),
+		slogx.WithFormat("logfmt"),
+		slogx.WithDisableTime(),
+		slogx.WithDisableSource(),
+		slogx.WithPackageLevels("warn"),
+		slogx.WithSampling(slogx.SamplingHandlerOptions{First: 1}),
+		slogx.WithDedup(slogx.DedupHandlerOptions{}),
+		slogx.WithLevelSignals())
+	defer logger.Close(context.Background())
+
+	for _, want := range []slog.Level{slog.LevelInfo, slog.LevelDebug} {
+		syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+		waitLevel(t, logger.Levels(), want)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) && strings.Count(buf.String(), "\n") < 2 {
+		time.Sleep(5 * time.Millisecond)
+	}
+	checkLogOutput(t, buf.String(), `level=INFO msg="log level changed" from=warn to=info signal="user defined signal 1"~`+
+		`level=INFO msg="log level changed" from=info to=debug signal="user defined signal 1"`)
+}
@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ttys3/slogx"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in   string
+		want slog.Level
+	}{
+		{"trace", slogx.LevelTrace},
+		{"DEBUG", slog.LevelDebug},
+		{"Notice", slogx.LevelNotice},
+		{"fatal", slogx.LevelFatal},
+		{"-8", slogx.LevelTrace},
+		{"12", slogx.LevelFatal},
+		{"INFO+2", slogx.LevelNotice},
+		{"error-1", slog.LevelError - 1},
+		{" warn ", slog.LevelWarn},
+	}
+	for _, tt := range tests {
+		got, err := slogx.ParseLevel(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "verbose", "info+x", "info+"} {
+		if _, err := slogx.ParseLevel(in); err == nil {
+			t.Errorf("ParseLevel(%q) succeeded", in)
+		}
+	}
+}
+
+func TestLevelName(t *testing.T) {
+	tests := []struct {
+		level slog.Level
+		want  string
+	}{
+		{slogx.LevelTrace, "TRACE"},
+		{slog.LevelInfo, "INFO"},
+		{slogx.LevelNotice, "NOTICE"},
+		{slogx.LevelNotice + 1, "NOTICE+1"},
+		{slogx.LevelFatal + 2, "FATAL+2"},
+		{slogx.LevelTrace - 1, "TRACE-1"},
+	}
+	for _, tt := range tests {
+		if got := slogx.LevelName(tt.level); got != tt.want {
+			t.Errorf("LevelName(%d) = %s, want %s", tt.level, got, tt.want)
+		}
+		if got, err := slogx.ParseLevel(tt.want); err != nil || got != tt.level {
+			t.Errorf("ParseLevel(%s) = %v, %v, want %d", tt.want, got, err, tt.level)
+		}
+	}
+}
+
+func TestSlogxCustomLevels(t *testing.T) {
+	for _, format := range []string{"json", "text", "logfmt"} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slogx.NewLogger(slogx.WithFormat(format),
+				slogx.WithLevel("trace"),
+				slogx.WithWriter(&buf),
+				slogx.WithDisableTime(),
+				slogx.WithDisableSource())
+			logger.Trace("trace")
+			logger.Notice("notice", "id", 1)
+			logger.Log(context.Background(), slogx.LevelNotice+1, "above notice")
+
+			switch format {
+			case "json":
+				checkLogOutput(t, buf.String(),
+					`\{"level":"TRACE","msg":"trace"\}~`+
+						`\{"level":"NOTICE","msg":"notice","id":1\}~`+
+						`\{"level":"NOTICE\+1","msg":"above notice"\}`)
+			default:
+				checkLogOutput(t, buf.String(),
+					`level=TRACE msg=trace~`+
+						`level=NOTICE msg=notice id=1~`+
+						`level=NOTICE\+1 msg="above notice"`)
+			}
+		})
+	}
+}
+
+func TestSlogxCustomLevelsCli(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slogx.NewLogger(slogx.WithFormat("cli"),
+		slogx.WithLevel("trace-4"),
+		slogx.WithWriter(&buf),
+		slogx.WithDisableColor(),
+		slogx.WithDisableTime(),
+		slogx.WithDisableSource())
+	logger.Trace("trace")
+	logger.Notice("notice")
+	logger.Log(context.Background(), slogx.LevelTrace-4, "below trace")
+
+	checkLogOutput(t, buf.String(), `.*·.* trace.*~.*•.* notice.*~.*·.* below trace.*`)
+}
+
+func TestSlogxLevelHelpersSource(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slogx.NewLogger(slogx.WithWriter(&buf), slogx.WithDisableTime())
+	logger.Notice("notice")
+
+	if !strings.Contains(buf.String(), `"source":"tests/levels_test.go:`) {
+		t.Errorf("source is not the caller of Notice: %s", buf.String())
+	}
+}
+
+func TestSlogxFatal(t *testing.T) {
+	path := os.Getenv("SLOGX_TEST_FATAL_FILE")
+	if path != "" {
+		logger := slogx.NewLogger(slogx.WithOutput(path), slogx.WithAsync(slogx.AsyncHandlerOptions{}), slogx.WithDisableTime(), slogx.WithDisableSource())
+		logger.Fatal("fatal", "id", 1)
+		return
+	}
+
+	path = filepath.Join(t.TempDir(), "fatal.log")
+	cmd := exec.Command(os.Args[0], "-test.run=^TestSlogxFatal$")
+	cmd.Env = append(os.Environ(), "SLOGX_TEST_FATAL_FILE="+path)
+	err := cmd.Run()
+	if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 1 {
+		t.Fatalf("Fatal exit = %v, want exit status 1", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the async queue is flushed before exiting
+	checkLogOutput(t, string(data), `\{"level":"FATAL","msg":"fatal","id":1\}`)
+}
+
+func TestRegisterLevel(t *testing.T) {
+	for _, name := range []string{"", " ", "PRE-PROD", "INFO+1", "a b", "12"} {
+		if err := slogx.RegisterLevel(20, name); err == nil {
+			t.Errorf("RegisterLevel(%q) succeeded", name)
+		}
+	}
+
+	if err := slogx.RegisterLevel(20, "panic"); err != nil {
+		t.Fatal(err)
+	}
+	if got := slogx.LevelName(21); got != "PANIC+1" {
+		t.Errorf("LevelName(21) = %s, want PANIC+1", got)
+	}
+	if got, err := slogx.ParseLevel("Panic"); err != nil || got != 20 {
+		t.Errorf("ParseLevel(Panic) = %v, %v, want 20", got, err)
+	}
+}
@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ttys3/slogx"
+)
+
+func TestSlogxLoggerClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	logger := slogx.NewLogger(slogx.WithOutput(path),
+		slogx.WithFormat("logfmt"),
+		slogx.WithDisableTime(),
+		slogx.WithDisableSource())
+
+	logger.Info("before close")
+	if err := logger.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if err := logger.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// closing twice is a no-op
+	if err := logger.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("after close")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkLogOutput(t, string(data), `level=INFO msg="before close"`)
+}
+
+func TestSlogxShutdown(t *testing.T) {
+	dir := t.TempDir()
+	a := slogx.New(slogx.WithOutput(filepath.Join(dir, "a.log")), slogx.WithFormat("logfmt"))
+	b := slogx.New(slogx.WithOutput(filepath.Join(dir, "b.log")), slogx.WithFormat("logfmt"),
+		slogx.WithRotation(slogx.Rotation{MaxSize: 1024}))
+	a.Info("a")
+	b.Info("b")
+
+	if err := slogx.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.log", "b.log"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) == 0 {
+			t.Fatalf("%s is empty", name)
+		}
+	}
+}
+
+func TestSlogxShutdownDeadline(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := slogx.Shutdown(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Shutdown() = %v, want %v", err, context.Canceled)
+	}
+}
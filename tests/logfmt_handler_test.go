@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ttys3/slogx"
+)
+
+func TestSlogxLogfmt(t *testing.T) {
+	var buf bytes.Buffer
+	mw := io.MultiWriter(&buf, os.Stderr)
+	slog.SetDefault(slogx.New(slogx.WithFormat("logfmt"),
+		slogx.WithWriter(mw),
+		slogx.WithDisableTime()))
+
+	l := slog.With("name", "Al Smith")
+	l.WithGroup("req").Info("hello world", "age", 18, slog.Group("user", "id", 1, "email", ""))
+	checkLogOutput(t, buf.String(), `level=INFO msg="hello world" source=tests/logfmt_handler_test.go:\d+ name="Al Smith" req.age=18 req.user.id=1 req.user.email=""`)
+	buf.Reset()
+
+	slog.Warn("quote", "path", `C:\tmp`, "text", "a \"b\"\nc", "eq", "a=b", "ctrl", "\x00")
+	checkLogOutput(t, buf.String(), `level=WARN msg=quote source=tests/logfmt_handler_test.go:\d+ path="C:\\\\tmp" text="a \\"b\\"\\nc" eq="a=b" ctrl="\\u0000"`)
+	buf.Reset()
+}
+
+func TestSlogxLogfmtKeyOrder(t *testing.T) {
+	var buf bytes.Buffer
+	mw := io.MultiWriter(&buf, os.Stderr)
+	handler := slogx.NewLogfmtHandler(mw, &slogx.LogfmtHandlerOptions{
+		KeyOrder: []string{slog.MessageKey, slog.LevelKey},
+	})
+	slog.New(handler).Info("hello", "k", "v")
+	checkLogOutput(t, buf.String(), `msg=hello level=INFO time=\S+ k=v`)
+}
+
+func TestSlogxLogfmtRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slogx.NewLogfmtHandler(&buf, nil)
+	logger := slog.New(handler)
+
+	values := []string{
+		"",
+		"plain",
+		"with space",
+		`"quoted"`,
+		`back\slash`,
+		"key=value",
+		"new\nline\ttab\rreturn",
+		"\x00\x1b[31mred\x7f",
+		"unicode ✓ 日本語",
+	}
+	for _, v := range values {
+		buf.Reset()
+		at := time.Date(2023, 8, 1, 12, 30, 0, 123, time.UTC)
+		r := slog.NewRecord(at, slog.LevelError, v, 0)
+		r.AddAttrs(slog.String("value", v), slog.Group("g", slog.String("value", v)))
+		if err := logger.Handler().Handle(context.Background(), r); err != nil {
+			t.Fatal(err)
+		}
+
+		fields, err := slogx.ParseLogfmt(buf.String())
+		if err != nil {
+			t.Fatalf("parse %q: %v", buf.String(), err)
+		}
+		want := []slogx.LogfmtField{
+			{Key: "time", Value: "2023-08-01T12:30:00.000000123Z"},
+			{Key: "level", Value: "ERROR"},
+			{Key: "msg", Value: v},
+			{Key: "value", Value: v},
+			{Key: "g.value", Value: v},
+		}
+		if !reflect.DeepEqual(fields, want) {
+			t.Errorf("round trip of %q:\ngot  %q\nwant %q", v, fields, want)
+		}
+	}
+}
+
+func TestParseLogfmt(t *testing.T) {
+	fields, err := slogx.ParseLogfmt(`a=1 b="x y" flag c= d="\u00e9\"" `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []slogx.LogfmtField{
+		{Key: "a", Value: "1"},
+		{Key: "b", Value: "x y"},
+		{Key: "flag"},
+		{Key: "c"},
+		{Key: "d", Value: `é"`},
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("got %q, want %q", fields, want)
+	}
+
+	for _, line := range []string{`a="unterminated`, `a=b"c`, `=x`, `a="\q"`} {
+		if _, err := slogx.ParseLogfmt(line); err == nil {
+			t.Errorf("parse %q: expected error", line)
+		}
+	}
+}
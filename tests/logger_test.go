@@ -3,6 +3,7 @@ package tests
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -137,6 +138,147 @@ func TestSlogxCliColorApexDemoDisableColor(t *testing.T) {
 	l.Error(fmt.Sprintf("failed to upload %s", "img.png"))
 }
 
+func TestSlogxCliTag(t *testing.T) {
+	var buf bytes.Buffer
+	mw := io.MultiWriter(&buf, os.Stderr)
+	handler := slogx.NewCliHandler(mw, &slogx.CliHandlerOptions{
+		DisableColor: true,
+		TagKey:       "component",
+		TagFromGroup: true,
+	})
+	l := slog.New(handler)
+
+	l.With("component", "db").Info("connected", "addr", "127.0.0.1")
+	checkLogOutput(t, buf.String(), `\S*\s*•\S* \[db\]\s{7}connected\s*\t\t addr=127.0.0.1`)
+	buf.Reset()
+
+	l.WithGroup("http").Info("listening", "port", 8080)
+	checkLogOutput(t, buf.String(), `\S*\s*•\S* \[http\]\s{5}listening\s*\t\t port=8080`)
+	buf.Reset()
+
+	l.Info("record tag", "component", "cache")
+	checkLogOutput(t, buf.String(), `\S*\s*•\S* \[cache\]\s{4}record tag\s*\t\t`)
+	buf.Reset()
+
+	l.Info("untagged")
+	checkLogOutput(t, buf.String(), `\S*\s*•\S* \s{11}untagged\s*\t\t`)
+	buf.Reset()
+
+	l.Info("long tag", "component", "scheduler")
+	checkLogOutput(t, buf.String(), `\S*\s*•\S* \[schedul…\] long tag\s*\t\t`)
+	buf.Reset()
+}
+
+func TestSlogxTagOptions(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slogx.NewLogger(slogx.WithFormat("cli"),
+		slogx.WithWriter(&buf),
+		slogx.WithDisableColor(),
+		slogx.WithDisableTime(),
+		slogx.WithDisableSource(),
+		slogx.WithTagFromGroup(),
+		slogx.WithTagWidth(4))
+	defer logger.Close(context.Background())
+
+	logger.WithGroup("db").Info("connected", "addr", "127.0.0.1")
+	logger.WithGroup("http").Info("listening", "port", 8080)
+	checkLogOutput(t, buf.String(), `\S*\s*•\S* \[db\]\s{3}connected\s*\t\t addr=127.0.0.1~`+
+		`\S*\s*•\S* \[http\]\s{1}listening\s*\t\t port=8080`)
+}
+
+func TestSlogxCliCollapseAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	mw := io.MultiWriter(&buf, os.Stderr)
+	handler := slogx.NewCliHandler(mw, &slogx.CliHandlerOptions{
+		DisableColor:  true,
+		CollapseAttrs: slogx.CollapseMark,
+	})
+	slog.SetDefault(slog.New(handler))
+
+	l := slog.With("file", "something.png", "type", "image/png", "user", "tobi")
+	l.Info("upload")
+	l.Info("upload complete", "size", 1024)
+	l.Warn("upload retry")
+	slog.Info("other")
+	l.With("err", errors.New("unauthorized")).Error("upload failed")
+	l.Error("upload failed again")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	want := []string{
+		`.* upload\s*\t\t file=something.png type=image/png user=tobi`,
+		`.* upload complete\s*\t\t \S*〃\S* size=1024`,
+		`.* upload retry\s*\t\t \S*〃\S*`,
+		`.* other\s*\t\t`,
+		`.* upload failed\s*\t\t file=something.png type=image/png user=tobi err=unauthorized`,
+		`.* upload failed again\s*\t\t file=something.png type=image/png user=tobi`,
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(want), buf.String())
+	}
+	for i, w := range want {
+		checkLogOutput(t, lines[i], w)
+	}
+}
+
+func TestSlogxCollapseAttrsHide(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slogx.NewLogger(slogx.WithFormat("cli"),
+		slogx.WithWriter(&buf),
+		slogx.WithDisableColor(),
+		slogx.WithDisableTime(),
+		slogx.WithDisableSource(),
+		slogx.WithCollapseAttrs(slogx.CollapseHide))
+	defer logger.Close(context.Background())
+
+	l := logger.With("user", "tobi")
+	l.Info("upload")
+	l.Info("upload complete", "size", 1024)
+	checkLogOutput(t, buf.String(), `.* upload\s*\t\t user=tobi~.* upload complete\s*\t\t size=1024`)
+}
+
+func TestSlogxPrettyJSON(t *testing.T) {
+	var buf bytes.Buffer
+	mw := io.MultiWriter(&buf, os.Stderr)
+	slog.SetDefault(slogx.New(slogx.WithFormat("pretty-json"),
+		slogx.WithWriter(mw),
+		slogx.WithDisableTime(),
+		slogx.WithDisableColor()))
+
+	l := slog.With("name", "Al", slog.Group("empty"))
+	l.WithGroup("req").Info("hello", "age", 18, "ok", true, "tags", []string{"a", "b"}, "quote", `"x"`)
+	checkLogOutput(t, buf.String(), `{~`+
+		`  "level": "INFO",~`+
+		`  "source": "tests/logger_test.go:\d+",~`+
+		`  "msg": "hello",~`+
+		`  "name": "Al",~`+
+		`  "req": {~`+
+		`    "age": 18,~`+
+		`    "ok": true,~`+
+		`    "tags": \[~`+
+		`      "a",~`+
+		`      "b"~`+
+		`    \],~`+
+		`    "quote": "\\"x\\""~`+
+		`  }~`+
+		`}`)
+	buf.Reset()
+
+	// same shape as the json format
+	var compact bytes.Buffer
+	slog.New(slog.NewJSONHandler(&compact, nil)).Info("hello", "age", 18)
+	slog.Info("hello", "age", 18)
+	var got, want map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(compact.Bytes(), &want); err != nil {
+		t.Fatal(err)
+	}
+	if got["msg"] != want["msg"] || got["age"] != want["age"] || got["level"] != want["level"] {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
 func TestSlogJsonWith(t *testing.T) {
 	ctx := context.Background()
 	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, nil)))
@@ -218,3 +360,43 @@ func TestSlogxText(t *testing.T) {
 	checkLogOutput(t, buf.String(), `level=ERROR source=tests/logger_test.go:\d+ msg=oops err="use of closed network connection" status=500`)
 	buf.Reset()
 }
+
+func TestSlogxNewE(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []slogx.Option
+		wantErr string
+	}{
+		{"valid", []slogx.Option{slogx.WithLevel("debug"), slogx.WithFormat("logfmt"), slogx.WithOutput("discard")}, ""},
+		{"unknown level", []slogx.Option{slogx.WithLevel("verbose")}, `unknown level "verbose"`},
+		{"unknown format", []slogx.Option{slogx.WithFormat("xml")}, `unknown format "xml"`},
+		{"conflicting source", []slogx.Option{slogx.WithFullSource(), slogx.WithDisableSource()}, "FullSource and DisableSource are exclusive"},
+		{"unwritable path", []slogx.Option{slogx.WithOutput("/nonexistent/dir/app.log")}, "open log file"},
+		{"rotation without file", []slogx.Option{slogx.WithRotation(slogx.Rotation{MaxSize: 1})}, "rotation needs a file output"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, err := slogx.NewE(tt.opts...)
+			if tt.wantErr == "" {
+				if err != nil || logger == nil {
+					t.Fatalf("NewE() = %v, %v", logger, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("NewE() error = %v, want %q", err, tt.wantErr)
+			}
+			if _, err := slogx.NewHandlerE(tt.opts...); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("NewHandlerE() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// the lenient constructor falls back to defaults
+	if slogx.New(slogx.WithLevel("verbose"), slogx.WithFormat("xml"), slogx.WithOutput("discard")) == nil {
+		t.Fatal("New() = nil")
+	}
+	if slogx.NewHandler(slogx.WithLevel("verbose"), slogx.WithOutput("discard")) == nil {
+		t.Fatal("NewHandler() = nil")
+	}
+}
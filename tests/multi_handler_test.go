@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ttys3/slogx"
+)
+
+func TestSlogxWithSink(t *testing.T) {
+	var cli bytes.Buffer
+	path := filepath.Join(t.TempDir(), "app.log")
+	logger := slogx.NewLogger(slogx.WithFormat("cli"),
+		slogx.WithWriter(&cli),
+		slogx.WithDisableColor(),
+		slogx.WithDisableTime(),
+		slogx.WithDisableSource(),
+		slogx.WithSink("json", path, "debug"))
+	defer logger.Close(context.Background())
+
+	l := logger.With("name", "Al").WithGroup("req")
+	l.Debug("only in the file", "id", 1)
+	l.Info("everywhere", "id", 2)
+
+	checkLogOutput(t, cli.String(), `.* everywhere .*name=Al req.id=2`)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkLogOutput(t, string(data),
+		`\{"level":"DEBUG","msg":"only in the file","name":"Al","req":\{"id":1\}\}~`+
+			`\{"level":"INFO","msg":"everywhere","name":"Al","req":\{"id":2\}\}`)
+}
+
+type errorHandler struct {
+	slog.Handler
+	err error
+}
+
+func (h errorHandler) Handle(context.Context, slog.Record) error {
+	return h.err
+}
+
+func TestMultiHandler(t *testing.T) {
+	var buf bytes.Buffer
+	errA := errors.New("a failed")
+	errB := errors.New("b failed")
+	h := slogx.Multi(
+		errorHandler{Handler: slog.NewTextHandler(&buf, nil), err: errA},
+		slog.NewTextHandler(&buf, &slog.HandlerOptions{
+			Level: slog.LevelWarn,
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return a
+			},
+		}),
+		errorHandler{Handler: slog.NewTextHandler(&buf, nil), err: errB},
+	)
+
+	if !h.Enabled(context.Background(), slog.LevelInfo) {
+		t.Fatal("Enabled(info) = false, want true")
+	}
+	if h.Enabled(context.Background(), slog.LevelDebug) {
+		t.Fatal("Enabled(debug) = true, want false")
+	}
+
+	err := slog.New(h).Handler().Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelWarn, "hello", 0))
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Fatalf("Handle() = %v, want both errors", err)
+	}
+	checkLogOutput(t, buf.String(), `level=WARN msg=hello`)
+}
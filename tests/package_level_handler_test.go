@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ttys3/slogx"
+	"gopkg.in/yaml.v3"
+)
+
+func TestPackageLevels(t *testing.T) {
+	levels, err := slogx.ParsePackageLevels("warn, github.com/acme/db=debug,github.com/acme=error,github.com/acme/http/=info", slog.LevelInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]slog.Level{
+		"main":                     slog.LevelWarn,
+		"github.com/acme":          slog.LevelError,
+		"github.com/acme/db":       slog.LevelDebug,
+		"github.com/acme/db/sql":   slog.LevelDebug,
+		"github.com/acme/dbx":      slog.LevelError,
+		"github.com/acme/http/mux": slog.LevelInfo,
+	}
+	for pkg, want := range tests {
+		if got := levels.Level(pkg); got != want {
+			t.Errorf("Level(%q) = %v, want %v", pkg, got, want)
+		}
+	}
+	if levels.Min() != slog.LevelDebug {
+		t.Errorf("Min() = %v, want %v", levels.Min(), slog.LevelDebug)
+	}
+
+	if _, err := slogx.ParsePackageLevels("github.com/acme=verbose", slog.LevelInfo); err == nil {
+		t.Error("ParsePackageLevels() with an unknown level succeeded")
+	}
+}
+
+func TestPackageLevelHandler(t *testing.T) {
+	var buf bytes.Buffer
+	levels, _ := slogx.ParsePackageLevels("warn,github.com/ttys3/slogx/tests=debug", slog.LevelInfo)
+	h := slogx.NewPackageLevelHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}), levels)
+	logger := slog.New(h).With("name", "Al")
+
+	logger.Debug("debug from tests")
+	if !strings.Contains(buf.String(), "debug from tests") {
+		t.Fatalf("debug record of the tests package dropped: %q", buf.String())
+	}
+
+	// the rules are updated for the derived loggers too
+	levels, _ = slogx.ParsePackageLevels("debug,github.com/ttys3/slogx=error", slog.LevelInfo)
+	h.SetLevels(levels)
+	buf.Reset()
+	logger.Warn("warn from tests")
+	if buf.Len() != 0 {
+		t.Fatalf("warn record of the tests package logged: %q", buf.String())
+	}
+}
+
+func TestPackageLevelHandlerDottedPath(t *testing.T) {
+	var buf bytes.Buffer
+	levels, _ := slogx.ParsePackageLevels("info,gopkg.in/yaml.v3=debug", slog.LevelInfo)
+	h := slogx.NewPackageLevelHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}), levels)
+
+	// the function name of a record from yaml.v3 is gopkg.in/yaml%2ev3.Marshal
+	pc := reflect.ValueOf(yaml.Marshal).Pointer() + 1
+	if err := h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelDebug, "debug from yaml", pc)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "debug from yaml") {
+		t.Fatalf("debug record of gopkg.in/yaml.v3 dropped: %q", buf.String())
+	}
+}
+
+func TestSlogxWithPackageLevels(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slogx.New(slogx.WithWriter(&buf),
+		slogx.WithFormat("logfmt"),
+		slogx.WithDisableTime(),
+		slogx.WithDisableSource(),
+		slogx.WithPackageLevels("error,github.com/ttys3/slogx/tests=debug"))
+
+	logger.Debug("hello")
+	checkLogOutput(t, buf.String(), `level=DEBUG msg=hello`)
+}
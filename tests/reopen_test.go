@@ -0,0 +1,189 @@
+//go:build !windows
+
+package tests
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ttys3/slogx"
+)
+
+func TestSlogxReopenOnSignal(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	logger := slogx.NewLogger(slogx.WithOutput(path),
+		slogx.WithFormat("logfmt"),
+		slogx.WithDisableTime(),
+		slogx.WithDisableSource(),
+		slogx.WithReopenSignal(syscall.SIGUSR1))
+	defer logger.Close(context.Background())
+
+	logger.Info("before")
+	// logrotate moves the file away, then signals the process
+	rotated := path + ".1"
+	if err := os.Rename(path, rotated); err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("still in the moved file")
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	logger.Info("after")
+
+	data, err := os.ReadFile(rotated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkLogOutput(t, string(data), `level=INFO msg=before~level=INFO msg="still in the moved file"`)
+	data, err = os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkLogOutput(t, string(data), `level=INFO msg=after`)
+}
+
+func TestReopenableFileConcurrentWrites(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	f, err := slogx.OpenReopenableFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	const writers, lines = 4, 200
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < lines; i++ {
+				fmt.Fprintf(f, "writer=%d line=%d\n", w, i)
+			}
+		}(w)
+	}
+	for i := 0; i < 10; i++ {
+		if err := os.Rename(path, fmt.Sprintf("%s.%d", path, i)); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Reopen(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+
+	var all strings.Builder
+	matches, _ := filepath.Glob(path + "*")
+	for _, name := range matches {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		all.Write(data)
+	}
+	got := strings.Split(strings.TrimSuffix(all.String(), "\n"), "\n")
+	if len(got) != writers*lines {
+		t.Fatalf("got %d lines, want %d", len(got), writers*lines)
+	}
+	for _, line := range got {
+		checkLogOutput(t, line, `writer=\d line=\d+`)
+	}
+}
+
+func TestRotatingFileReopenFailure(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	f, err := slogx.NewRotatingFile(path, slogx.Rotation{MaxSize: 1 << 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// logrotate moved the file, and the path can't be opened
+	moved := filepath.Join(dir, "app.log.1")
+	if err := os.Rename(path, moved); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(path, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Reopen(); err == nil {
+		t.Fatal("Reopen() of a directory succeeded")
+	}
+	// the old file is kept
+	if _, err := f.Write([]byte("kept\n")); err != nil {
+		t.Fatalf("Write() after a failed Reopen() = %v", err)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("new\n")); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{moved: "kept\n", path: "new\n"} {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != want {
+			t.Errorf("%s = %q, want %q", name, data, want)
+		}
+	}
+}
+
+func TestRotatingFileRotateFailure(t *testing.T) {
+	dir := t.TempDir()
+	// the backup name, with its timestamp, is too long to rename to
+	path := filepath.Join(dir, strings.Repeat("a", 240)+".log")
+	f, err := slogx.NewRotatingFile(path, slogx.Rotation{MaxSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write([]byte("first\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Rotate(); err == nil {
+		t.Fatal("Rotate() to a too long name succeeded")
+	}
+	// the current file is kept
+	if _, err := f.Write([]byte("ok\n")); err != nil {
+		t.Fatalf("Write() after a failed Rotate() = %v", err)
+	}
+	// the rotation is retried and fails again, the record still goes to the current file
+	if n, err := f.Write([]byte("second\n")); err == nil || n != len("second\n") {
+		t.Fatalf("Write() with a failing rotation = %d, %v", n, err)
+	}
+	if err := f.Sync(); err != nil {
+		t.Fatalf("Sync() after a failed rotation = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "first\nok\nsecond\n"; string(data) != want {
+		t.Errorf("%s = %q, want %q", path, data, want)
+	}
+}
@@ -0,0 +1,161 @@
+package tests
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ttys3/slogx"
+)
+
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestSlogxRotationBySize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	logger := slogx.New(slogx.WithOutput(path),
+		slogx.WithFormat("logfmt"),
+		slogx.WithDisableTime(),
+		slogx.WithDisableSource(),
+		slogx.WithRotation(slogx.Rotation{MaxSize: 100, MaxBackups: 2}))
+
+	// each line is 45 bytes, 2 lines fit in a file
+	for i := 0; i < 7; i++ {
+		logger.Info("hello", "line", strings.Repeat("x", 18))
+		// backups are named after the rotation time in milliseconds
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	// wait for the removal of old backups
+	deadline := time.Now().Add(5 * time.Second)
+	for len(listDir(t, dir)) != 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	names := listDir(t, dir)
+	if len(names) != 3 || names[2] != "app.log" {
+		t.Fatalf("got files %v, want the current file and 2 backups", names)
+	}
+	for _, name := range names[:2] {
+		if !strings.HasPrefix(name, "app-") || !strings.HasSuffix(name, ".log") {
+			t.Errorf("unexpected backup name %s", name)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data) > 100 || strings.Count(string(data), "\n") != 2 {
+			t.Errorf("backup %s: unexpected content %q", name, data)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkLogOutput(t, string(data), `level=INFO msg=hello line=x{18}`)
+}
+
+func TestRotatingFileCompress(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	f, err := slogx.NewRotatingFile(path, slogx.Rotation{Every: slogx.RotateDaily, Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("first\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("second\n")); err != nil {
+		t.Fatal(err)
+	}
+	// close waits for the compression
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	backup := "app-" + time.Now().Format("2006-01-02") + ".log.gz"
+	names := listDir(t, dir)
+	if len(names) != 2 || names[0] != backup || names[1] != "app.log" {
+		t.Fatalf("got files %v, want [%s app.log]", names, backup)
+	}
+
+	gzFile, err := os.Open(filepath.Join(dir, backup))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gzFile.Close()
+	gz, err := gzip.NewReader(gzFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil || string(data) != "first\n" {
+		t.Errorf("backup content %q, %v", data, err)
+	}
+	data, err = os.ReadFile(path)
+	if err != nil || string(data) != "second\n" {
+		t.Errorf("current content %q, %v", data, err)
+	}
+
+	if _, err := f.Write([]byte("closed\n")); err == nil {
+		t.Error("expected an error writing to a closed file")
+	}
+}
+
+func TestRotatingFileKeepsSiblings(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	old := time.Now().Add(-48 * time.Hour)
+	for _, name := range []string{"app-access.log", "app-2024-01-02-error.log"} {
+		sibling := filepath.Join(dir, name)
+		if err := os.WriteFile(sibling, []byte("other\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(sibling, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := slogx.NewRotatingFile(path, slogx.Rotation{MaxSize: 1 << 20, MaxBackups: 1, MaxAge: time.Hour, Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := f.Write([]byte("line\n")); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+	// close waits for the retention
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	names := listDir(t, dir)
+	if len(names) != 4 || names[0] != "app-2024-01-02-error.log" || !strings.HasSuffix(names[1], ".log.gz") ||
+		names[2] != "app-access.log" || names[3] != "app.log" {
+		t.Fatalf("got files %v, want the siblings, the current file and 1 compressed backup", names)
+	}
+}
@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"bytes"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ttys3/slogx"
+)
+
+func TestSamplingHandler(t *testing.T) {
+	var buf bytes.Buffer
+	var sampledOut []int
+	h := slogx.NewSamplingHandler(slogx.NewLogfmtHandler(&buf, nil), &slogx.SamplingHandlerOptions{
+		Tick:       time.Hour,
+		First:      2,
+		Thereafter: 3,
+		KeyAttrs:   []string{"path"},
+		OnSampledOut: func(r slog.Record, count int) {
+			sampledOut = append(sampledOut, count)
+		},
+	})
+	logger := slog.New(h)
+
+	for i := 0; i < 10; i++ {
+		logger.Info("request", "path", "/a", "i", i)
+	}
+	// another key is counted apart
+	logger.With("name", "Al").Info("request", "path", "/b", "i", 0)
+	// so is a key attr added by With
+	logger.With("path", "/c").Info("request", "i", 0)
+	// errors are never sampled
+	for i := 0; i < 5; i++ {
+		logger.Error("failed", "i", i)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"path=/a i=0", "path=/a i=1", "path=/a i=4", "path=/a i=7", "path=/b i=0", "path=/c i=0"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if got := strings.Count(out, "msg=request"); got != 6 {
+		t.Errorf("%d requests logged, want 6:\n%s", got, out)
+	}
+	if got := strings.Count(out, "msg=failed"); got != 5 {
+		t.Errorf("%d errors logged, want 5:\n%s", got, out)
+	}
+	if want := []int{1, 2, 3, 4, 5, 6}; !slices.Equal(sampledOut, want) {
+		t.Errorf("sampled out counts = %v, want %v", sampledOut, want)
+	}
+}
+
+func TestSlogxWithSampling(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slogx.New(slogx.WithWriter(&buf),
+		slogx.WithFormat("logfmt"),
+		slogx.WithSampling(slogx.SamplingHandlerOptions{First: 1}))
+	for i := 0; i < 3; i++ {
+		logger.Info("hello")
+	}
+	if got := strings.Count(buf.String(), "msg=hello"); got != 1 {
+		t.Fatalf("%d records logged, want 1:\n%s", got, buf.String())
+	}
+}
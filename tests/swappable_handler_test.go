@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ttys3/slogx"
+)
+
+func TestSwappableHandler(t *testing.T) {
+	var before, after bytes.Buffer
+	h := slogx.NewSwappableHandler(slog.NewJSONHandler(&before, &slog.HandlerOptions{ReplaceAttr: removeTime}))
+	logger := slog.New(h).With("name", "Al").WithGroup("req")
+
+	logger.Info("before", "id", 1)
+	old := h.Swap(slog.NewTextHandler(&after, &slog.HandlerOptions{ReplaceAttr: removeTime}))
+	if _, ok := old.(*slog.JSONHandler); !ok {
+		t.Fatalf("Swap returned %T, want the previous handler", old)
+	}
+	logger.Info("after", "id", 2)
+	// derived after the swap
+	logger.With("user", "Bob").Info("derived", "id", 3)
+
+	checkLogOutput(t, before.String(), `\{"level":"INFO","msg":"before","name":"Al","req":\{"id":1\}\}`)
+	checkLogOutput(t, after.String(),
+		`level=INFO msg=after name=Al req.id=2~`+
+			`level=INFO msg=derived name=Al req.user=Bob req.id=3`)
+}
+
+func TestSwappableHandlerEnabled(t *testing.T) {
+	var buf bytes.Buffer
+	h := slogx.NewSwappableHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	logger := slog.New(h).With("name", "Al")
+	if logger.Enabled(context.Background(), slog.LevelInfo) {
+		t.Error("info enabled with a warn handler")
+	}
+	h.Swap(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	if !logger.Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("debug disabled after swapping a debug handler")
+	}
+}
+
+func TestSwappableHandlerConcurrent(t *testing.T) {
+	var buf syncBuffer
+	h := slogx.NewSwappableHandler(slog.NewTextHandler(&buf, nil))
+	logger := slog.New(h).With("name", "Al")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				logger.WithGroup("req").Info("hello", "id", j)
+			}
+		}()
+	}
+	for i := 0; i < 50; i++ {
+		if i%2 == 0 {
+			h.Swap(slog.NewJSONHandler(&buf, nil))
+		} else {
+			h.Swap(slog.NewTextHandler(&buf, nil))
+		}
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 8*200 {
+		t.Fatalf("got %d lines, want %d", len(lines), 8*200)
+	}
+	for _, line := range lines {
+		if !strings.Contains(line, "name=Al") && !strings.Contains(line, `"name":"Al"`) {
+			t.Fatalf("line without the attrs of the logger: %s", line)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ttys3/slogx"
+)
+
+func TestSlogxSyslogUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	logger := slogx.New(slogx.WithOutput("syslog+udp://"+pc.LocalAddr().String()+"?facility=local0&app=myapp&hostname=myhost"),
+		slogx.WithLevel("debug"))
+	logger.With("user", "tobi").WithGroup("req").Warn("upload retry", "file", `a "b".png`, "size", 1024)
+
+	checkLogOutput(t, readPacket(t, pc), `<132>1 \S+ myhost myapp \d+ - \[slog@32473 source="tests/syslog_handler_test.go:\d+" user="tobi" req.file="a \\"b\\".png" req.size="1024"\] upload retry`)
+
+	logger.Debug("no attrs", "source", "]")
+	checkLogOutput(t, readPacket(t, pc), `<135>1 \S+ myhost myapp \d+ - \[slog@32473 source="tests/syslog_handler_test.go:\d+" source="\\]"\] no attrs`)
+}
+
+func TestSlogxWriterOverridesSyslog(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := slogx.NewE(slogx.WithOutput("syslog+tcp://127.0.0.1:1"),
+		slogx.WithWriter(&buf),
+		slogx.WithFormat("logfmt"),
+		slogx.WithDisableTime(),
+		slogx.WithDisableSource(),
+		slogx.WithPriorityPrefix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("hello")
+	checkLogOutput(t, buf.String(), `<6>level=INFO msg=hello`)
+}
+
+func TestSlogxSyslogUDPSplit(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	const maxSize = 100
+	logger := slogx.New(slogx.WithOutput("syslog+udp://"+pc.LocalAddr().String()+"?app=myapp&hostname=myhost&max_size="+strconv.Itoa(maxSize)),
+		slogx.WithDisableSource())
+	msg := strings.Repeat("0123456789", 12)
+	logger.Info(msg, "k", "v")
+
+	var got string
+	for len(got) < len(msg) {
+		packet := readPacket(t, pc)
+		if len(packet) > maxSize {
+			t.Errorf("packet larger than %d bytes: %q", maxSize, packet)
+		}
+		if got == "" {
+			checkLogOutput(t, packet, `<14>1 \S+ myhost myapp \d+ - \[slog@32473 k="v"\] \d+`)
+			got = packet[strings.Index(packet, "] ")+2:]
+			continue
+		}
+		checkLogOutput(t, packet, `<14>1 \S+ myhost myapp \d+ - - \d+`)
+		got += packet[strings.Index(packet, " - - ")+5:]
+	}
+	if got != msg {
+		t.Errorf("got %q, want %q", got, msg)
+	}
+}
+
+func TestSlogxSyslogTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	messages := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					length, err := r.ReadString(' ')
+					if err != nil {
+						return
+					}
+					n, _ := strconv.Atoi(strings.TrimSpace(length))
+					msg := make([]byte, n)
+					if _, err := io.ReadFull(r, msg); err != nil {
+						return
+					}
+					messages <- string(msg)
+				}
+			}()
+		}
+	}()
+
+	handler, w, err := slogx.NewSyslogHandlerFromURL("syslog+tcp://"+ln.Addr().String()+"?rfc=3164&app=myapp&hostname=myhost", slog.HandlerOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	logger := slog.New(handler)
+
+	logger.Error("upload failed", "err", io.ErrUnexpectedEOF)
+	checkLogOutput(t, receive(t, messages), `<11>\w{3} [ \d]\d \d\d:\d\d:\d\d myhost myapp\[\d+\]: upload failed err="unexpected EOF"`)
+
+	// the writer reconnects after the connection is closed
+	w.Close()
+	logger.Info("reconnected")
+	checkLogOutput(t, receive(t, messages), `<14>.* myapp\[\d+\]: reconnected`)
+}
+
+func readPacket(t *testing.T, pc net.PacketConn) string {
+	t.Helper()
+	buf := make([]byte, 4096)
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(string(buf[:n]))
+	return string(buf[:n])
+}
+
+func receive(t *testing.T, messages chan string) string {
+	t.Helper()
+	select {
+	case msg := <-messages:
+		t.Log(msg)
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for syslog message")
+		return ""
+	}
+}
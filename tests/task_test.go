@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ttys3/slogx"
+)
+
+func TestSlogxCliTask(t *testing.T) {
+	var buf bytes.Buffer
+	mw := io.MultiWriter(&buf, os.Stderr)
+	logger := slog.New(slogx.NewCliHandler(mw, &slogx.CliHandlerOptions{
+		DisableColor: true,
+		HandlerOptions: slog.HandlerOptions{
+			Level: slog.LevelDebug,
+		},
+	}))
+
+	ctx := context.Background()
+	task := slogx.StartTask(ctx, logger, "compiling")
+	logger.InfoContext(task.Context(), "main.go")
+	sub := slogx.StartTask(task.Context(), logger, "linking")
+	logger.DebugContext(sub.Context(), "libfoo.a")
+	sub.End(errors.New("undefined symbol"))
+	task.End(nil)
+	logger.Info("done")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	want := []struct {
+		indent string
+		symbol string
+		text   string
+	}{
+		{"", "•", "compiling"},
+		{"  ", "•", "main.go"},
+		{"  ", "•", "linking"},
+		{"    ", "•", "libfoo.a"},
+		{"  ", "⨯", "linking"},
+		{"", "✓", "compiling"},
+		{"", "•", "done"},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(want), buf.String())
+	}
+	for i, w := range want {
+		checkLogOutput(t, lines[i], w.indent+`\S*\s*`+w.symbol+`\S* `+w.text+`.*`)
+	}
+	checkLogOutput(t, lines[4], `.*\t\t \S+s err=undefined symbol`)
+	checkLogOutput(t, lines[5], `.*\t\t \S+s`)
+	if strings.Contains(buf.String(), "task=") {
+		t.Errorf("task group should not be rendered by the cli handler:\n%s", buf.String())
+	}
+}
+
+func TestSlogxJsonTask(t *testing.T) {
+	var buf bytes.Buffer
+	mw := io.MultiWriter(&buf, os.Stderr)
+	logger := slogx.New(slogx.WithWriter(mw), slogx.WithDisableTime())
+
+	task := slogx.StartTask(context.Background(), logger, "compiling")
+	checkLogOutput(t, buf.String(), `{"level":"INFO","source":"tests/task_test.go:\d+","msg":"compiling","task":{"event":"start"}}`)
+	buf.Reset()
+
+	task.End(io.ErrUnexpectedEOF)
+	checkLogOutput(t, buf.String(), `{"level":"ERROR","source":"tests/task_test.go:\d+","msg":"compiling","task":{"event":"end","duration":\d+},"err":"unexpected EOF"}`)
+}